@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"common/compare"
+	"common/retry"
+)
+
+func compareIDsFromFile(ctx context.Context, args []string) error {
+	fs := newFlagSet("compare ids-from-file", "Compares the no. of files per ID in bucket and bucket2 for the IDs in -input\n(one ID per line, or CSV with an id column and fields for the templates).")
+	input := fs.String("input", "file.txt", "file with the IDs to compare")
+	return runComparison(ctx, fs, args, func(side, side2 compare.Side, opts compare.Options, logger *log.Logger) (compare.Outcomes, error) {
+		return compare.FileBasedComparison(ctx, side, side2, *input, opts, logger)
+	})
+}
+
+func compareIDsFromBucket(ctx context.Context, args []string) error {
+	fs := newFlagSet("compare ids-from-bucket", "Compares the no. of files per ID in bucket and bucket2 for the IDs found under -root-prefix2 in bucket2.")
+	return runComparison(ctx, fs, args, func(side, side2 compare.Side, opts compare.Options, logger *log.Logger) (compare.Outcomes, error) {
+		return compare.BucketBasedComparison(ctx, side, side2, opts, logger)
+	})
+}
+
+// runComparison parses the flags shared by the compare commands, opens both
+// buckets and the output file, then runs the comparison. It fails when more than
+// -max-error-rate of the IDs could not be compared.
+func runComparison(ctx context.Context, fs *flag.FlagSet, args []string, run func(side, side2 compare.Side, opts compare.Options, logger *log.Logger) (compare.Outcomes, error)) error {
+	flags := newSideFlags(fs, "", "")
+	flags2 := newSideFlags(fs, "2", "uuid")
+	output := fs.String("output", "output.txt", "file the report is written to, - for stdout")
+	diffOutput := fs.String("diff-output", "", "if set, diff the object names of each ID and write the ones only in one bucket to this CSV file (id,where,name)")
+	diffBoth := fs.Bool("diff-both", false, "also write the names found in both buckets to -diff-output")
+	checkpoint := fs.String("checkpoint", "", "if set, append the outcome of each ID to this JSONL file as soon as it is known")
+	resume := fs.Bool("resume", false, "take the IDs already in -checkpoint from it instead of comparing them again")
+	retryErrors := fs.Bool("retry-errors", false, "with -resume, compare the IDs that failed in -checkpoint again")
+	overwrite := fs.Bool("overwrite", false, "start -checkpoint over even if it has the entries of an earlier run")
+	maxErrorRate := fs.Float64("max-error-rate", defaultMaxErrorRate, "fail when more than this share (0-1) of the IDs errored, timed out or were skipped")
+	var opts compare.Options
+	fs.IntVar(&opts.Workers, "workers", 64, "IDs compared concurrently (ids-from-bucket)")
+	fs.DurationVar(&opts.Timeout, "timeout", 5*time.Minute, "max wait for the file counts of one ID")
+	fs.IntVar(&opts.Threshold, "threshold", 50, "report IDs with at least this many more files in bucket than bucket2")
+	fs.Var((*intsFlag)(&opts.Histogram.Boundaries), "bins", "comma-separated upper bounds of the bins for IDs with more files in bucket, e.g. 4,10,20 (default the original 4,10,20,...,500)")
+	fs.Var((*intsFlag)(&opts.Histogram.RevBoundaries), "bins-rev", "same for IDs with more files in bucket2 (default 10,20,30,40,50,100)")
+	fs.BoolVar(&opts.Histogram.Percent, "bins-percent", false, "bins are the diff in percent of the smaller count instead of the no. of files")
+	fs.BoolVar(&opts.Verify, "verify", false, "also compare the objects of each ID by checksum (GCS CRC32C/MD5, OCI MD5), hashing them where none is stored")
+	sequence := fs.Bool("sequence", false, "also parse the segment sequence numbers from the object names and report gaps, duplicates and out-of-order uploads")
+	sequencePattern := fs.String("sequence-pattern", compare.DefaultSequencePattern.String(), "regexp for the sequence number in an object name, from a group named seq or the first group")
+	fs.BoolVar(&opts.Playlists, "playlists", false, "also check the .m3u8 playlists of each ID against its objects; the <id>,<dur1>,<dur2> lines then use the #EXTINF sums")
+	fs.BoolVar(&opts.MPD, "mpd", false, "with -playlists, also check DASH .mpd manifests")
+	durationFrom := fs.String("duration", "", "how the <id>,<dur1>,<dur2> lines work out the length of a recording: files (no. of files * -segment-duration), manifest (#EXTINF sums), metadata (sum of -duration-key) or timestamps (first to last upload); default files, or manifest with -playlists")
+	segment := fs.Duration("segment-duration", compare.SegmentDuration, "length of one segment, also used by -duration manifest and metadata for IDs without one")
+	durationKey := fs.String("duration-key", "duration", "custom metadata key with the length of a segment in sec, for -duration metadata")
+	fs.Var((*rulesFlag)(&opts.Rules), "rule", "repeatable flag rule '<name>: <expr>', e.g. 'Truncated: abs_diff < 5 && bytes_ratio < 0.9'; each lists the IDs it matches under its name (default 'MoreThan<threshold>: diff >= <threshold>')")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.Histogram.Validate(); err != nil {
+		return fmt.Errorf("-bins: %w", err)
+	}
+	if opts.MPD && !opts.Playlists {
+		return errors.New("-mpd: only used with -playlists")
+	}
+	if *maxErrorRate < 0 || *maxErrorRate > 1 {
+		return fmt.Errorf("-max-error-rate: %v: must be between 0 and 1", *maxErrorRate)
+	}
+	if *sequence {
+		pattern, err := compare.ParseSequencePattern(*sequencePattern)
+		if err != nil {
+			return fmt.Errorf("-sequence-pattern: %w", err)
+		}
+		opts.Sequence = pattern
+	}
+	durations, err := durationProvider(*durationFrom, *segment, *durationKey, opts.MPD)
+	if err != nil {
+		return fmt.Errorf("-duration: %w", err)
+	}
+	opts.Durations = durations
+	if *resume && *checkpoint == "" {
+		return errors.New("-resume: only used with -checkpoint")
+	}
+	if *retryErrors && !*resume {
+		return errors.New("-retry-errors: only used with -resume")
+	}
+	if *overwrite && (*checkpoint == "" || *resume) {
+		return errors.New("-overwrite: only used with -checkpoint, without -resume")
+	}
+
+	var retries retry.Metrics
+	side, closeStore, err := flags.open(ctx, flagName(""), &retries)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	side2, closeStore2, err := flags2.open(ctx, flagName("2"), &retries)
+	if err != nil {
+		return err
+	}
+	defer closeStore2()
+
+	// Open or create the output file
+	outputFile, err := createOutput(*output)
+	if err != nil {
+		return err
+	}
+	defer outputFile.Close()
+	if *diffOutput != "" {
+		diffFile, err := createOutput(*diffOutput)
+		if err != nil {
+			return err
+		}
+		defer diffFile.Close()
+		opts.Diff = compare.NewDiffWriter(diffFile, *diffBoth)
+	}
+
+	logger := log.New(outputFile, "", 0)
+	if *checkpoint != "" {
+		if opts.Checkpoint, err = openCheckpoint(*checkpoint, *resume, *retryErrors, *overwrite, logger); err != nil {
+			return err
+		}
+		defer opts.Checkpoint.Close()
+	}
+
+	outcomes, err := run(side, side2, opts, logger)
+	if err != nil {
+		return err
+	}
+	logger.Printf("Retries: %v\n", retries.Stats())
+	if opts.Diff != nil {
+		if err := opts.Diff.Flush(); err != nil {
+			return err
+		}
+	}
+	return checkErrorRate(outcomes, *maxErrorRate)
+}
+
+// defaultMaxErrorRate is the share of IDs that may fail before a comparison does.
+const defaultMaxErrorRate = 0.01
+
+// checkErrorRate fails a comparison in which more than maxRate of the IDs were not compared.
+func checkErrorRate(outcomes compare.Outcomes, maxRate float64) error {
+	if rate := outcomes.ErrorRate(); rate > maxRate {
+		return fmt.Errorf("%d of %d ID(s) not compared (%.1f%%), above the max error rate of %.1f%%", len(outcomes.Failures), outcomes.Total(), rate*100, maxRate*100)
+	}
+	return nil
+}
+
+// durationProvider returns the compare.DurationProvider named from, or nil for
+// the default of compare.Options.
+func durationProvider(from string, segment time.Duration, key string, mpd bool) (compare.DurationProvider, error) {
+	if segment <= 0 {
+		return nil, fmt.Errorf("segment duration %v: must be positive", segment)
+	}
+	fixed := compare.FixedDuration{Segment: segment}
+	switch from {
+	case "":
+		if segment == compare.SegmentDuration {
+			return nil, nil
+		}
+		return fixed, nil
+	case "files":
+		return fixed, nil
+	case "manifest":
+		return compare.ManifestDuration{MPD: mpd, Fallback: fixed}, nil
+	case "metadata":
+		if key == "" {
+			return nil, errors.New("metadata: no key")
+		}
+		return compare.MetadataDuration{Key: key, Fallback: fixed}, nil
+	case "timestamps":
+		return compare.TimestampDuration{Segment: segment}, nil
+	}
+	return nil, fmt.Errorf("unknown duration source %q, want files, manifest, metadata or timestamps", from)
+}
+
+// openCheckpoint opens the checkpoint file at path, see compare.OpenCheckpoint.
+func openCheckpoint(path string, resume, retryErrors, overwrite bool, logger *log.Logger) (*compare.Checkpoint, error) {
+	mode := compare.Fresh
+	switch {
+	case retryErrors:
+		mode = compare.RetryErrors
+	case resume:
+		mode = compare.ResumeAll
+	case overwrite:
+		mode = compare.Overwrite
+	}
+	checkpoint, err := compare.OpenCheckpoint(path, mode)
+	if errors.Is(err, compare.ErrCheckpointExists) {
+		return nil, fmt.Errorf("%w, resume from it or overwrite it", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resume {
+		logger.Printf("Resuming from checkpoint '%s': %d ID(s)\n", path, checkpoint.Len())
+	}
+	return checkpoint, nil
+}
+
+// intsFlag is a comma-separated list of ints, e.g. 4,10,20.
+type intsFlag []int
+
+func (f *intsFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	parts := make([]string, len(*f))
+	for i, v := range *f {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *intsFlag) Set(value string) error {
+	*f = nil
+	for _, part := range strings.Split(value, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return err
+		}
+		*f = append(*f, v)
+	}
+	return nil
+}
+
+// rulesFlag collects repeated -rule flags, see compare.ParseRule.
+type rulesFlag []compare.Rule
+
+func (f *rulesFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	names := make([]string, len(*f))
+	for i, rule := range *f {
+		names[i] = rule.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func (f *rulesFlag) Set(value string) error {
+	rule, err := compare.ParseRule(value)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, rule)
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"common/compare"
+	"common/objectstore"
+	"common/retry"
+)
+
+// storeSpec describes one side of a comparison, from flags or from a job file.
+// Flag names are the keys with "-" for "_", e.g. -root-prefix for root_prefix.
+type storeSpec struct {
+	Provider   string `yaml:"provider" toml:"provider"`
+	Bucket     string `yaml:"bucket" toml:"bucket"`
+	RootPrefix string `yaml:"root_prefix" toml:"root_prefix"`
+	Template   string `yaml:"template" toml:"template"`
+	IDDepth    int    `yaml:"id_depth" toml:"id_depth"`
+	IDPattern  string `yaml:"id_pattern" toml:"id_pattern"`
+
+	Credentials string `yaml:"credentials" toml:"credentials"`
+	Endpoint    string `yaml:"endpoint" toml:"endpoint"`
+	Emulator    bool   `yaml:"emulator" toml:"emulator"`
+	Namespace   string `yaml:"namespace" toml:"namespace"`
+	OCIConfig   string `yaml:"oci_config" toml:"oci_config"`
+	OCIProfile  string `yaml:"oci_profile" toml:"oci_profile"`
+	Region      string `yaml:"region" toml:"region"`
+	PathStyle   bool   `yaml:"path_style" toml:"path_style"`
+	Insecure    bool   `yaml:"insecure" toml:"insecure"`
+	PageSize    int    `yaml:"page_size" toml:"page_size"`
+}
+
+// newSideFlags registers the flags for one side on fs. suffix is appended to
+// every flag name, e.g. "2" gives -bucket2, -root-prefix2, ...
+func newSideFlags(fs *flag.FlagSet, suffix, idPattern string) *storeSpec {
+	s := &storeSpec{}
+	fs.StringVar(&s.Provider, "provider"+suffix, objectstore.ProviderGCS, "storage provider: gcs, oci, s3 or fs")
+	fs.StringVar(&s.Bucket, "bucket"+suffix, "", "bucket name (for fs, the directory the bucket was dumped to)")
+	fs.StringVar(&s.RootPrefix, "root-prefix"+suffix, "", "prefix the IDs live under, e.g. CompositePreProcessing/v2/")
+	fs.StringVar(&s.Template, "template"+suffix, "", "prefix holding an ID's files, e.g. {date}/{id}/segments/; empty means <root-prefix><id>")
+	fs.IntVar(&s.IDDepth, "id-depth"+suffix, 0, "no. of folder levels between the root prefix and the IDs, e.g. 3 for <yyyy>/<mm>/<dd>/")
+	fs.StringVar(&s.IDPattern, "id-pattern"+suffix, idPattern, "regexp an ID folder must match, \"uuid\" for UUIDs, empty for any")
+	fs.StringVar(&s.Credentials, "credentials"+suffix, "", "GCS service account key file; empty uses application default credentials")
+	fs.StringVar(&s.Endpoint, "endpoint"+suffix, "", "GCS or S3 endpoint, e.g. localhost:4443 for a local fake-gcs-server (with -emulator); a bare host:port uses HTTPS")
+	fs.BoolVar(&s.Emulator, "emulator"+suffix, false, "GCS endpoint is an emulator: plain HTTP and no credentials (implied by STORAGE_EMULATOR_HOST; needs -endpoint without it)")
+	fs.StringVar(&s.Namespace, "namespace"+suffix, "", "OCI object storage namespace")
+	fs.StringVar(&s.OCIConfig, "oci-config"+suffix, "", "OCI config file; empty uses ~/.oci/config")
+	fs.StringVar(&s.OCIProfile, "oci-profile"+suffix, "", "OCI config profile; empty uses DEFAULT")
+	fs.StringVar(&s.Region, "region"+suffix, "", "S3 region")
+	fs.BoolVar(&s.PathStyle, "path-style"+suffix, false, "S3 path-style addressing, e.g. for MinIO")
+	fs.BoolVar(&s.Insecure, "insecure"+suffix, false, "S3 over plain HTTP")
+	fs.IntVar(&s.PageSize, "page-size"+suffix, 0, "objects per list call (OCI and S3); 0 uses the service default")
+	return s
+}
+
+// flagName maps a storeSpec key to the flag for the side with suffix.
+func flagName(suffix string) func(key string) string {
+	return func(key string) string {
+		return "-" + strings.ReplaceAll(key, "_", "-") + suffix
+	}
+}
+
+// fieldError is a bad setting; field is its flag, or its path in a job file.
+type fieldError struct {
+	field string
+	msg   string
+}
+
+func (e *fieldError) Error() string {
+	return e.field + ": " + e.msg
+}
+
+// validate checks the spec and returns one error per bad setting, named by name(key).
+func (s *storeSpec) validate(name func(key string) string) []error {
+	var errs []error
+	bad := func(key, format string, args ...any) {
+		errs = append(errs, &fieldError{field: name(key), msg: fmt.Sprintf(format, args...)})
+	}
+	switch s.Provider {
+	case objectstore.ProviderGCS, objectstore.ProviderOCI, objectstore.ProviderS3, objectstore.ProviderFS:
+	case "":
+		bad("provider", "required")
+	default:
+		bad("provider", "unknown provider %q, want gcs, oci, s3 or fs", s.Provider)
+	}
+	if s.Bucket == "" {
+		bad("bucket", "required")
+	}
+	if s.Provider == objectstore.ProviderOCI && s.Namespace == "" {
+		bad("namespace", "required for oci")
+	}
+	if s.Provider == objectstore.ProviderS3 && s.Endpoint == "" {
+		bad("endpoint", "required for s3")
+	}
+	if s.Emulator && s.Provider != objectstore.ProviderGCS {
+		bad("emulator", "only for gcs")
+	} else if s.Emulator && s.Endpoint == "" && os.Getenv("STORAGE_EMULATOR_HOST") == "" {
+		// Otherwise the client would reach the real GCS with real credentials
+		bad("emulator", "requires endpoint or STORAGE_EMULATOR_HOST")
+	}
+	if s.Template != "" {
+		if err := compare.ValidateTemplate(s.Template); err != nil {
+			bad("template", "%v", err)
+		}
+	}
+	if s.IDDepth < 0 {
+		bad("id_depth", "must not be negative")
+	}
+	if _, err := s.pattern(); err != nil {
+		bad("id_pattern", "%v", err)
+	}
+	if s.PageSize < 0 {
+		bad("page_size", "must not be negative")
+	}
+	return errs
+}
+
+// pattern compiles IDPattern; "uuid" is compare.UUIDPattern and empty accepts any ID.
+func (s *storeSpec) pattern() (*regexp.Regexp, error) {
+	switch s.IDPattern {
+	case "":
+		return nil, nil
+	case "uuid":
+		return compare.UUIDPattern, nil
+	}
+	return regexp.Compile(s.IDPattern)
+}
+
+// open validates the spec, connects to the bucket and returns the side to
+// compare. The returned func releases the client. S3 credentials are read
+// from AWS_* / MINIO_* env vars. Transient errors of the provider are retried
+// and counted in retries.
+func (s *storeSpec) open(ctx context.Context, name func(key string) string, retries *retry.Metrics) (compare.Side, func(), error) {
+	if err := errors.Join(s.validate(name)...); err != nil {
+		return compare.Side{}, nil, err
+	}
+	pattern, _ := s.pattern()
+
+	store, closeStore, err := objectstore.Open(ctx, objectstore.Config{
+		Provider:        s.Provider,
+		Bucket:          s.Bucket,
+		CredentialsFile: s.Credentials,
+		Emulator:        s.Emulator,
+		Namespace:       s.Namespace,
+		ConfigFile:      s.OCIConfig,
+		Profile:         s.OCIProfile,
+		PageSize:        s.PageSize,
+		Endpoint:        s.Endpoint,
+		Region:          s.Region,
+		PathStyle:       s.PathStyle,
+		Insecure:        s.Insecure,
+		Retry:           retries,
+	})
+	if err != nil {
+		return compare.Side{}, nil, fmt.Errorf("failed to open bucket '%s': %w", s.Bucket, err)
+	}
+	side := compare.Side{
+		Store:      store,
+		RootPrefix: s.RootPrefix,
+		Template:   s.Template,
+		IDDepth:    s.IDDepth,
+		IDPattern:  pattern,
+	}
+	return side, closeStore, nil
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"common/compare"
+	"common/retry"
+)
+
+// job is a comparison described in a YAML or TOML file, see jobs/ for examples.
+// Relative paths (ids.file, checkpoint.file, outputs) are relative to the working directory.
+type job struct {
+	Source       storeSpec  `yaml:"source" toml:"source"` // bucket1, e.g. prod
+	Target       storeSpec  `yaml:"target" toml:"target"` // bucket2, e.g. temp
+	IDs          idSource   `yaml:"ids" toml:"ids"`
+	Workers      int        `yaml:"workers" toml:"workers"`
+	Timeout      duration   `yaml:"timeout" toml:"timeout"`
+	Threshold    int        `yaml:"threshold" toml:"threshold"`
+	Histogram    histogram  `yaml:"histogram" toml:"histogram"`
+	Rules        []rule     `yaml:"rules" toml:"rules"`
+	Verify       bool       `yaml:"verify" toml:"verify"` // compare the objects by checksum, see compare.VerifyID
+	Diff         diff       `yaml:"diff" toml:"diff"`
+	Sequence     sequence   `yaml:"sequence" toml:"sequence"`
+	Playlists    playlists  `yaml:"playlists" toml:"playlists"`
+	Duration     lengths    `yaml:"duration" toml:"duration"`
+	Checkpoint   checkpoint `yaml:"checkpoint" toml:"checkpoint"`
+	MaxErrorRate *float64   `yaml:"max_error_rate" toml:"max_error_rate"` // share (0-1) of IDs that may fail before the run does, default 0.01
+	Outputs      []string   `yaml:"outputs" toml:"outputs"`               // files the report is written to, - for stdout
+}
+
+// idSource selects the IDs to compare.
+type idSource struct {
+	From string `yaml:"from" toml:"from"` // "target" lists them from the target bucket, "file" reads File
+	File string `yaml:"file" toml:"file"` // see compare.ReadRecords
+}
+
+// histogram sets the bins of the summary, see compare.Histogram.
+type histogram struct {
+	Boundaries    []int `yaml:"boundaries" toml:"boundaries"`
+	RevBoundaries []int `yaml:"rev_boundaries" toml:"rev_boundaries"`
+	Percent       bool  `yaml:"percent" toml:"percent"`
+}
+
+// rule is a named flag rule, see compare.ParseRule for the syntax of When.
+type rule struct {
+	Name string `yaml:"name" toml:"name"`
+	When string `yaml:"when" toml:"when"`
+}
+
+// diff writes the object names only in one bucket (or both) to a CSV file, see compare.DiffNames.
+type diff struct {
+	Output string `yaml:"output" toml:"output"`
+	Both   bool   `yaml:"both" toml:"both"`
+}
+
+// sequence checks the segment sequence numbers, see compare.AnalyzeSequence.
+type sequence struct {
+	Check   bool   `yaml:"check" toml:"check"`
+	Pattern string `yaml:"pattern" toml:"pattern"` // default compare.DefaultSequencePattern
+}
+
+// playlists checks the playlists of each ID, see compare.ValidatePlaylists.
+type playlists struct {
+	Check bool `yaml:"check" toml:"check"`
+	MPD   bool `yaml:"mpd" toml:"mpd"` // also DASH manifests
+}
+
+// checkpoint persists the outcome of each ID so a run can be resumed, see compare.Checkpoint.
+type checkpoint struct {
+	File        string `yaml:"file" toml:"file"`                 // JSONL file
+	Resume      bool   `yaml:"resume" toml:"resume"`             // take the IDs already in File from it
+	RetryErrors bool   `yaml:"retry_errors" toml:"retry_errors"` // with Resume, compare the IDs that failed again
+	Overwrite   bool   `yaml:"overwrite" toml:"overwrite"`       // start File over even if it has entries
+}
+
+// lengths sets how the length of a recording is worked out, see compare.DurationProvider.
+type lengths struct {
+	From    string   `yaml:"from" toml:"from"`       // files, manifest, metadata or timestamps
+	Segment duration `yaml:"segment" toml:"segment"` // default compare.SegmentDuration
+	Key     string   `yaml:"key" toml:"key"`         // metadata key, default "duration"
+}
+
+// provider returns the compare.DurationProvider of l, see durationProvider.
+func (l lengths) provider(mpd bool) (compare.DurationProvider, error) {
+	segment, key := l.Segment.Duration, l.Key
+	if segment == 0 {
+		segment = compare.SegmentDuration
+	}
+	if key == "" {
+		key = "duration"
+	}
+	return durationProvider(l.From, segment, key, mpd)
+}
+
+// duration is a time.Duration written as a string, e.g. "5m".
+type duration struct {
+	time.Duration
+}
+
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func runJob(ctx context.Context, args []string) error {
+	fs := newFlagSet("run", "Runs the comparison described in a YAML (.yaml, .yml) or TOML (.toml) job file.")
+	path := fs.String("job", "", "job file")
+	check := fs.Bool("check", false, "only validate the job file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return errors.New("-job is required")
+	}
+
+	j, err := loadJob(*path)
+	if err != nil || *check {
+		return err
+	}
+
+	var retries retry.Metrics
+	side, closeStore, err := j.Source.open(ctx, jobKey("source"), &retries)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+	side2, closeStore2, err := j.Target.open(ctx, jobKey("target"), &retries)
+	if err != nil {
+		return err
+	}
+	defer closeStore2()
+
+	var writers []io.Writer
+	for _, output := range j.Outputs {
+		outputFile, err := createOutput(output)
+		if err != nil {
+			return err
+		}
+		defer outputFile.Close()
+		writers = append(writers, outputFile)
+	}
+	logger := log.New(io.MultiWriter(writers...), "", 0)
+
+	opts := compare.Options{
+		Workers:   j.Workers,
+		Timeout:   j.Timeout.Duration,
+		Threshold: j.Threshold,
+		Histogram: compare.Histogram{
+			Boundaries:    j.Histogram.Boundaries,
+			RevBoundaries: j.Histogram.RevBoundaries,
+			Percent:       j.Histogram.Percent,
+		},
+		Verify:    j.Verify,
+		Playlists: j.Playlists.Check,
+		MPD:       j.Playlists.MPD,
+	}
+	for _, r := range j.Rules {
+		match, err := compare.ParsePredicate(r.When)
+		if err != nil {
+			return err // already checked by loadJob
+		}
+		opts.Rules = append(opts.Rules, compare.Rule{Name: r.Name, Match: match})
+	}
+	if j.Sequence.Check {
+		opts.Sequence = compare.DefaultSequencePattern
+		if j.Sequence.Pattern != "" {
+			if opts.Sequence, err = compare.ParseSequencePattern(j.Sequence.Pattern); err != nil {
+				return err // already checked by loadJob
+			}
+		}
+	}
+	if opts.Durations, err = j.Duration.provider(j.Playlists.MPD); err != nil {
+		return err // already checked by loadJob
+	}
+	if j.Diff.Output != "" {
+		diffFile, err := createOutput(j.Diff.Output)
+		if err != nil {
+			return err
+		}
+		defer diffFile.Close()
+		opts.Diff = compare.NewDiffWriter(diffFile, j.Diff.Both)
+	}
+	if j.Checkpoint.File != "" {
+		if opts.Checkpoint, err = openCheckpoint(j.Checkpoint.File, j.Checkpoint.Resume, j.Checkpoint.RetryErrors, j.Checkpoint.Overwrite, logger); err != nil {
+			return err
+		}
+		defer opts.Checkpoint.Close()
+	}
+	var outcomes compare.Outcomes
+	if j.IDs.From == "file" {
+		outcomes, err = compare.FileBasedComparison(ctx, side, side2, j.IDs.File, opts, logger)
+	} else {
+		outcomes, err = compare.BucketBasedComparison(ctx, side, side2, opts, logger)
+	}
+	if err != nil {
+		return err
+	}
+	logger.Printf("Retries: %v\n", retries.Stats())
+	if opts.Diff != nil {
+		if err := opts.Diff.Flush(); err != nil {
+			return err
+		}
+	}
+	maxErrorRate := defaultMaxErrorRate
+	if j.MaxErrorRate != nil {
+		maxErrorRate = *j.MaxErrorRate
+	}
+	return checkErrorRate(outcomes, maxErrorRate)
+}
+
+// loadJob parses and validates the job file at path. Errors name the bad field,
+// and for YAML its line, e.g. "job.yaml:7: target.template: ...".
+func loadJob(path string) (*job, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &job{}
+	var doc yaml.Node
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(j); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		// Kept to look up the line of a bad field
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		md, err := toml.Decode(string(data), j)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("%s: %s: unknown field", path, undecoded[0])
+		}
+	default:
+		return nil, fmt.Errorf("%s: unknown job file format %q, want .yaml, .yml or .toml", path, ext)
+	}
+
+	errs := j.validate()
+	for i, err := range errs {
+		var fe *fieldError
+		if errors.As(err, &fe) {
+			if line := yamlLine(&doc, fe.field); line > 0 {
+				errs[i] = fmt.Errorf("%s:%d: %w", path, line, err)
+				continue
+			}
+		}
+		errs[i] = fmt.Errorf("%s: %w", path, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// jobKey names the settings of a store in a job file, e.g. "target.bucket".
+func jobKey(store string) func(key string) string {
+	return func(key string) string {
+		return store + "." + key
+	}
+}
+
+func (j *job) validate() []error {
+	var errs []error
+	bad := func(field, format string, args ...any) {
+		errs = append(errs, &fieldError{field: field, msg: fmt.Sprintf(format, args...)})
+	}
+
+	errs = append(errs, j.Source.validate(jobKey("source"))...)
+	errs = append(errs, j.Target.validate(jobKey("target"))...)
+	switch j.IDs.From {
+	case "target":
+		if j.IDs.File != "" {
+			bad("ids.file", "only used with from: file")
+		}
+	case "file":
+		if j.IDs.File == "" {
+			bad("ids.file", "required with from: file")
+		}
+	case "":
+		bad("ids.from", "required")
+	default:
+		bad("ids.from", "unknown ID source %q, want target or file", j.IDs.From)
+	}
+	if j.Workers < 0 {
+		bad("workers", "must not be negative")
+	}
+	if j.Timeout.Duration < 0 {
+		bad("timeout", "must not be negative")
+	}
+	if j.Threshold < 0 {
+		bad("threshold", "must not be negative")
+	}
+	if err := (compare.Histogram{Boundaries: j.Histogram.Boundaries}).Validate(); err != nil {
+		bad("histogram.boundaries", "%v", err)
+	}
+	if err := (compare.Histogram{RevBoundaries: j.Histogram.RevBoundaries}).Validate(); err != nil {
+		bad("histogram.rev_boundaries", "%v", err)
+	}
+	names := make(map[string]bool)
+	for i, r := range j.Rules {
+		switch {
+		case r.Name == "":
+			bad(fmt.Sprintf("rules[%d].name", i), "required")
+		case names[r.Name]:
+			bad(fmt.Sprintf("rules[%d].name", i), "duplicate rule '%s'", r.Name)
+		}
+		names[r.Name] = true
+		if _, err := compare.ParsePredicate(r.When); err != nil {
+			bad(fmt.Sprintf("rules[%d].when", i), "%v", err)
+		}
+	}
+	if j.Sequence.Pattern != "" {
+		if !j.Sequence.Check {
+			bad("sequence.pattern", "only used with check: true")
+		} else if _, err := compare.ParseSequencePattern(j.Sequence.Pattern); err != nil {
+			bad("sequence.pattern", "%v", err)
+		}
+	}
+	if j.Playlists.MPD && !j.Playlists.Check {
+		bad("playlists.mpd", "only used with check: true")
+	}
+	if j.Duration.Segment.Duration < 0 {
+		bad("duration.segment", "must not be negative")
+	} else if _, err := j.Duration.provider(j.Playlists.MPD); err != nil {
+		bad("duration.from", "%v", err)
+	}
+	if j.Duration.Key != "" && j.Duration.From != "metadata" {
+		bad("duration.key", "only used with from: metadata")
+	}
+	if j.MaxErrorRate != nil && (*j.MaxErrorRate < 0 || *j.MaxErrorRate > 1) {
+		bad("max_error_rate", "must be between 0 and 1")
+	}
+	if j.Checkpoint.Resume && j.Checkpoint.File == "" {
+		bad("checkpoint.resume", "only used with checkpoint.file")
+	}
+	if j.Checkpoint.RetryErrors && !j.Checkpoint.Resume {
+		bad("checkpoint.retry_errors", "only used with resume: true")
+	}
+	if j.Checkpoint.Overwrite && (j.Checkpoint.File == "" || j.Checkpoint.Resume) {
+		bad("checkpoint.overwrite", "only used with checkpoint.file, without resume: true")
+	}
+	if j.Diff.Both && j.Diff.Output == "" {
+		bad("diff.both", "only used with diff.output")
+	}
+	if len(j.Outputs) == 0 {
+		bad("outputs", "required")
+	}
+	for i, output := range j.Outputs {
+		if output == "" {
+			bad(fmt.Sprintf("outputs[%d]", i), "empty path")
+		}
+	}
+	return errs
+}
+
+// yamlLine returns the line of field (e.g. "target.template" or "outputs[1]")
+// in doc. A missing field gives the line of its closest parent, 0 if none.
+func yamlLine(doc *yaml.Node, field string) int {
+	node, line := doc, 0
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, key := range strings.Split(field, ".") {
+		index := -1
+		if i := strings.IndexByte(key, '['); i >= 0 {
+			index, _ = strconv.Atoi(strings.TrimSuffix(key[i+1:], "]"))
+			key = key[:i]
+		}
+		if node.Kind != yaml.MappingNode {
+			return line
+		}
+		var value *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				line, value = node.Content[i].Line, node.Content[i+1]
+				break
+			}
+		}
+		if value == nil {
+			return line
+		}
+		node = value
+		if index >= 0 && node.Kind == yaml.SequenceNode && index < len(node.Content) {
+			node = node.Content[index]
+			line = node.Line
+		}
+	}
+	return line
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadJobExamples(t *testing.T) {
+	paths, err := filepath.Glob("jobs/*")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no example jobs: %v", err)
+	}
+	for _, path := range paths {
+		if _, err := loadJob(path); err != nil {
+			t.Errorf("loadJob(%s) error = %v", path, err)
+		}
+	}
+}
+
+func TestLoadJob(t *testing.T) {
+	t.Setenv("STORAGE_EMULATOR_HOST", "")
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr []string
+	}{
+		{
+			name: "valid toml",
+			file: "job.toml",
+			content: `outputs = ["-"]
+[source]
+provider = "fs"
+bucket = "prod"
+[target]
+provider = "fs"
+bucket = "temp"
+[ids]
+from = "target"
+`,
+		},
+		{
+			name: "bad fields point to their line",
+			file: "job.yaml",
+			content: `source:
+  provider: gcp
+  bucket: prod
+target:
+  provider: oci
+  bucket: temp
+  template: "{date}/"
+  emulator: true
+ids:
+  from: file
+outputs:
+  - output.txt
+  - ""
+`,
+			wantErr: []string{
+				`job.yaml:2: source.provider: unknown provider "gcp"`,
+				"job.yaml:4: target.namespace: required for oci",
+				"job.yaml:7: target.template: template '{date}/': missing {id}",
+				"job.yaml:8: target.emulator: only for gcs",
+				"job.yaml:9: ids.file: required with from: file",
+				"job.yaml:13: outputs[1]: empty path",
+			},
+		},
+		{
+			name:    "emulator without endpoint",
+			file:    "job.yaml",
+			content: "source:\n  provider: gcs\n  bucket: prod\n  emulator: true\n",
+			wantErr: []string{"job.yaml:4: source.emulator: requires endpoint or STORAGE_EMULATOR_HOST"},
+		},
+		{
+			name:    "missing sections",
+			file:    "job.yml",
+			content: "workers: -1\n",
+			wantErr: []string{"job.yml: source.provider: required", "job.yml: ids.from: required", "job.yml:1: workers: must not be negative", "job.yml: outputs: required"},
+		},
+		{
+			name:    "bad histogram",
+			file:    "job.yaml",
+			content: "histogram:\n  percent: true\n  boundaries: [10, 5]\n",
+			wantErr: []string{"job.yaml:3: histogram.boundaries: boundaries [10 5]: must be positive and ascending"},
+		},
+		{
+			name:    "bad rules",
+			file:    "job.yaml",
+			content: "rules:\n  - name: Gone\n    when: target_empty\n  - name: Gone\n    when: files > 1\n",
+			wantErr: []string{"job.yaml:4: rules[1].name: duplicate rule 'Gone'", `job.yaml:5: rules[1].when: term "files > 1": unknown metric 'files'`},
+		},
+		{
+			name:    "bad sequence pattern",
+			file:    "job.yaml",
+			content: "sequence:\n  check: true\n  pattern: 'segment_\\d+'\n",
+			wantErr: []string{`job.yaml:3: sequence.pattern: pattern 'segment_\d+': no group for the sequence number`},
+		},
+		{
+			name:    "mpd without playlists",
+			file:    "job.yaml",
+			content: "playlists:\n  mpd: true\n",
+			wantErr: []string{"job.yaml:2: playlists.mpd: only used with check: true"},
+		},
+		{
+			name:    "bad duration",
+			file:    "job.yaml",
+			content: "duration:\n  from: exif\n  segment: -1s\n  key: length\n",
+			wantErr: []string{"job.yaml:3: duration.segment: must not be negative", "job.yaml:4: duration.key: only used with from: metadata"},
+		},
+		{
+			name:    "unknown duration source",
+			file:    "job.toml",
+			content: "[duration]\nfrom = \"exif\"\n",
+			wantErr: []string{`job.toml: duration.from: unknown duration source "exif"`},
+		},
+		{
+			name:    "bad max error rate",
+			file:    "job.yaml",
+			content: "workers: 1\nmax_error_rate: 5\n",
+			wantErr: []string{"job.yaml:2: max_error_rate: must be between 0 and 1"},
+		},
+		{
+			name:    "retry errors without resume",
+			file:    "job.yaml",
+			content: "checkpoint:\n  retry_errors: true\n",
+			wantErr: []string{"job.yaml:2: checkpoint.retry_errors: only used with resume: true"},
+		},
+		{
+			name:    "overwrite with resume",
+			file:    "job.yaml",
+			content: "checkpoint:\n  file: checkpoint.jsonl\n  resume: true\n  overwrite: true\n",
+			wantErr: []string{"job.yaml:4: checkpoint.overwrite: only used with checkpoint.file, without resume: true"},
+		},
+		{
+			name:    "diff both without output",
+			file:    "job.toml",
+			content: "[diff]\nboth = true\n",
+			wantErr: []string{"job.toml: diff.both: only used with diff.output"},
+		},
+		{
+			name:    "unknown yaml field",
+			file:    "job.yaml",
+			content: "source:\n  bucket_name: prod\n",
+			wantErr: []string{"line 2: field bucket_name not found"},
+		},
+		{
+			name:    "unknown toml field",
+			file:    "job.toml",
+			content: "[target]\nbucket_name = \"temp\"\n",
+			wantErr: []string{"job.toml: target.bucket_name: unknown field"},
+		},
+		{
+			name:    "bad timeout",
+			file:    "job.toml",
+			content: "timeout = \"5 minutes\"\n",
+			wantErr: []string{"timeout"},
+		},
+		{
+			name:    "unknown format",
+			file:    "job.json",
+			content: "{}",
+			wantErr: []string{`unknown job file format ".json"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := loadJob(path)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("loadJob() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("loadJob() error = nil, want %q", tt.wantErr)
+			}
+			got := strings.ReplaceAll(err.Error(), dir+string(filepath.Separator), "")
+			for _, want := range tt.wantErr {
+				if !strings.Contains(got, want) {
+					t.Errorf("error missing %q, got:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	dir := t.TempDir()
+	prod, temp := filepath.Join(dir, "prod"), filepath.Join(dir, "temp")
+	writeFiles(t, prod, "v2/"+uuid1, 30)
+	writeFiles(t, temp, "v4/"+uuid1, 5)
+	writeFiles(t, prod, "v2/"+uuid2, 5)
+	writeFiles(t, temp, "v4/"+uuid2, 5)
+
+	output := filepath.Join(dir, "output.txt")
+	diffOutput := filepath.Join(dir, "diff.csv")
+	job := filepath.Join(dir, "job.yaml")
+	content := `source: {provider: fs, bucket: ` + prod + `, root_prefix: v2/}
+target: {provider: fs, bucket: ` + temp + `, root_prefix: v4/, id_pattern: uuid}
+ids: {from: target}
+workers: 1
+timeout: 1m
+threshold: 20
+rules:
+  - {name: MoreThan20, when: diff >= 20}
+  - {name: Low, when: ratio < 0.5}
+diff: {output: ` + diffOutput + `}
+outputs: [` + output + `]
+`
+	if err := os.WriteFile(job, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(context.Background(), []string{"run", "-job", job}); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+
+	out, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{uuid1 + ",450,75", "Total IDs in temp bucket: 2", `MoreThan20: ("` + uuid1 + `")`, `Low: ("` + uuid1 + `")`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output missing %q, got:\n%s", want, out)
+		}
+	}
+	diffOut, err := os.ReadFile(diffOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := uuid1 + ",source,segment_00029.ts\n"; !strings.HasPrefix(string(diffOut), "id,where,name\n") || !strings.HasSuffix(string(diffOut), want) {
+		t.Errorf("diff output = %q, want header and ending in %q", diffOut, want)
+	}
+	if j, _ := loadJob(job); j.Timeout.Duration != time.Minute {
+		t.Errorf("timeout = %v, want %v", j.Timeout.Duration, time.Minute)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"sort"
+
+	"common/compare"
+	"common/retry"
+)
+
+func listIDs(ctx context.Context, args []string) error {
+	fs := newFlagSet("list-ids", "Lists the IDs found under -root-prefix, one per line. When the IDs carry fields\n(-id-depth, named groups in -id-pattern) they are written as CSV, ready for 'compare ids-from-file'.\nThe retries of the listing follow as a # comment.")
+	flags := newSideFlags(fs, "", "uuid")
+	output := fs.String("output", "-", "file the IDs are written to, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var retries retry.Metrics
+	side, closeStore, err := flags.open(ctx, flagName(""), &retries)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
+	records, skipped, err := compare.GetUniqueIDs(ctx, side)
+	if err != nil {
+		return err
+	}
+	for _, prefix := range skipped {
+		log.Printf("Skipping prefix '%s': no ID matching the pattern", prefix)
+	}
+
+	// Columns are the union of the records' fields
+	var fields []string
+	seen := make(map[string]bool)
+	for _, rec := range records {
+		for name := range rec.Fields {
+			if !seen[name] {
+				seen[name] = true
+				fields = append(fields, name)
+			}
+		}
+	}
+	sort.Strings(fields)
+
+	outputFile, err := createOutput(*output)
+	if err != nil {
+		return err
+	}
+	defer outputFile.Close()
+
+	w := csv.NewWriter(outputFile)
+	if len(fields) > 0 {
+		w.Write(append([]string{"id"}, fields...))
+	}
+	for _, rec := range records {
+		row := []string{rec.ID}
+		for _, name := range fields {
+			row = append(row, rec.Fields[name])
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	// A comment, so the output can still be read by 'compare ids-from-file'
+	_, err = fmt.Fprintf(outputFile, "# Retries: %v\n", retries.Stats())
+	return err
+}
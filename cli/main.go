@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+const usage = `Usage: cli <command> [flags]
+
+Commands:
+  compare ids-from-file    compare no. of files per ID across 2 buckets, IDs read from -input
+  compare ids-from-bucket  compare no. of files per ID across 2 buckets, IDs listed from the 2nd bucket
+  spanner durations        join the durations in -input with the livestream durations in Spanner
+  list-ids                 list the IDs under -root-prefix in a bucket
+  run                      run the comparison described in a YAML or TOML job file
+
+Run 'cli <command> -h' for the flags of a command.
+`
+
+// command is a subcommand; run gets the arguments after the command name.
+type command struct {
+	name string
+	run  func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{"compare ids-from-file", compareIDsFromFile},
+	{"compare ids-from-bucket", compareIDsFromBucket},
+	{"spanner durations", spannerDurations},
+	{"list-ids", listIDs},
+	{"run", runJob},
+}
+
+func main() {
+	ctx, stop := interruptible(context.Background())
+	err := run(ctx, os.Args[1:])
+	stop()
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// interruptible returns a context cancelled by Ctrl-C or SIGTERM: a comparison then
+// stops, leaving the IDs in flight out of the checkpoint so -resume compares them.
+func interruptible(ctx context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+}
+
+func run(ctx context.Context, args []string) error {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && slices.Equal(args[:len(words)], words) {
+			return cmd.run(ctx, args[len(words):])
+		}
+	}
+	fmt.Fprint(os.Stderr, usage)
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		return flag.ErrHelp
+	}
+	return fmt.Errorf("unknown command %q", strings.Join(args, " "))
+}
+
+// newFlagSet returns a FlagSet that reports errors instead of exiting.
+func newFlagSet(name, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: cli %s [flags]\n\n%s\n\nFlags:\n", name, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// createOutput opens path for writing, "-" is stdout.
+func createOutput(path string) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+const (
+	uuid1 = "fc8a9074-87d7-4c08-a0cb-ed4c00e0e91d"
+	uuid2 = "003b8f49-1f87-4782-945b-497948c30bea"
+)
+
+// writeFiles creates n files under dir/prefix.
+func writeFiles(t *testing.T, dir, prefix string, n int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, prefix), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := os.WriteFile(filepath.Join(dir, prefix, fmt.Sprintf("segment_%05d.ts", i)), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	prod, temp := filepath.Join(dir, "prod"), filepath.Join(dir, "temp")
+	writeFiles(t, prod, "v2/"+uuid1, 60)
+	writeFiles(t, prod, "v2/"+uuid2, 5)
+	writeFiles(t, temp, "2024/12/01/"+uuid1, 3)
+	writeFiles(t, temp, "2024/12/02/"+uuid2, 5)
+	writeFiles(t, temp, "2024/12/02/tmp", 1)
+
+	ids := filepath.Join(dir, "ids.csv")
+	plainIDs := filepath.Join(dir, "ids.txt") // no parent for the templates
+	if err := os.WriteFile(plainIDs, []byte(uuid1+"\n"+uuid2+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bucketFlags := []string{"-provider", "fs", "-bucket", prod, "-root-prefix", "v2/", "-provider2", "fs", "-bucket2", temp}
+
+	tests := []struct {
+		name    string
+		args    []string
+		output  string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "list-ids",
+			args:   []string{"list-ids", "-provider", "fs", "-bucket", temp, "-id-depth", "3"},
+			output: ids,
+			want:   []string{"id,parent\n" + uuid1 + ",2024/12/01\n" + uuid2 + ",2024/12/02\n", "# Retries: 0 of 0 call(s) retried"},
+		},
+		{
+			name:   "compare ids-from-file",
+			args:   append([]string{"compare", "ids-from-file", "-input", ids, "-template2", "{parent}/{id}/"}, bucketFlags...),
+			output: filepath.Join(dir, "file.txt"),
+			want: []string{
+				"livestream '" + uuid1 + "': bucket1 '" + prod + "': 60 file(s): bucket2 '" + temp + "': 3 file(s); diff: 57",
+				"Total IDs with same files in prod bucket and temp bucket: 1",
+				"Retries: 0 of 0 call(s) retried", // fs is not retried
+			},
+		},
+		{
+			name:   "compare ids-from-bucket",
+			args:   append([]string{"compare", "ids-from-bucket", "-id-depth2", "3", "-template2", "{parent}/{id}/"}, bucketFlags...),
+			output: filepath.Join(dir, "bucket.txt"),
+			want:   []string{uuid1 + ",900,45", "Total IDs in temp bucket: 2", "Total prefixes skipped in temp bucket: 1"},
+		},
+		{
+			name:   "compare with custom bins",
+			args:   append([]string{"compare", "ids-from-file", "-input", ids, "-template2", "{parent}/{id}/", "-bins", "10", "-bins-rev", "10"}, bucketFlags...),
+			output: filepath.Join(dir, "bins.txt"),
+			want:   []string{"Total IDs with more than 10 more files in prod bucket than temp bucket: 1", `DiffMoreThan10: ("` + uuid1 + `")`},
+		},
+		{
+			name:   "compare with rules",
+			args:   append([]string{"compare", "ids-from-file", "-input", ids, "-template2", "{parent}/{id}/", "-rule", "Low: ratio < 0.5", "-rule", "Any: abs_diff > 0"}, bucketFlags...),
+			output: filepath.Join(dir, "rules.txt"),
+			want:   []string{`Low: ("` + uuid1 + `")`, `Any: ("` + uuid1 + `")`},
+		},
+		{
+			name:   "compare with verify",
+			args:   append([]string{"compare", "ids-from-bucket", "-id-depth2", "3", "-template2", "{parent}/{id}/", "-verify"}, bucketFlags...),
+			output: filepath.Join(dir, "verify.txt"),
+			want: []string{
+				"verify '" + uuid1 + "': 3 identical, 0 differing, 0 missing in bucket1 '" + prod + "', 57 missing in bucket2 '" + temp + "' (6 hashed)",
+				"Total IDs with identical objects in prod bucket and temp bucket: 1",
+			},
+		},
+		{
+			name:   "compare with name diff",
+			args:   append([]string{"compare", "ids-from-file", "-input", ids, "-template2", "{parent}/{id}/", "-diff-output", filepath.Join(dir, "diff.csv")}, bucketFlags...),
+			output: filepath.Join(dir, "diff.txt"),
+			want:   []string{"diff '" + uuid1 + "': 57 only in bucket1 '" + prod + "', 0 only in bucket2 '" + temp + "', 3 in both"},
+		},
+		{
+			name:   "compare with sequence",
+			args:   append([]string{"compare", "ids-from-file", "-input", ids, "-template2", "{parent}/{id}/", "-sequence", "-sequence-pattern", `segment_(?P<seq>\d+)`}, bucketFlags...),
+			output: filepath.Join(dir, "sequence.txt"),
+			want:   []string{"Total IDs with missing segments in temp bucket: 0", "Total IDs with duplicate segments in prod bucket: 0"},
+		},
+		{name: "bad sequence pattern", args: append([]string{"compare", "ids-from-bucket", "-sequence", "-sequence-pattern", "x"}, bucketFlags...), wantErr: "-sequence-pattern: pattern 'x': no group"},
+		{
+			name:   "compare with playlists",
+			args:   append([]string{"compare", "ids-from-file", "-input", ids, "-template2", "{parent}/{id}/", "-playlists", "-mpd"}, bucketFlags...),
+			output: filepath.Join(dir, "playlists.txt"),
+			want:   []string{"playlist '" + uuid1 + "': bucket2 '" + temp + "': no playlist", "Total IDs without playlist in temp bucket: 2"},
+		},
+		{
+			name:   "compare with segment duration",
+			args:   append([]string{"compare", "ids-from-bucket", "-id-depth2", "3", "-template2", "{parent}/{id}/", "-duration", "files", "-segment-duration", "10s"}, bucketFlags...),
+			output: filepath.Join(dir, "duration.txt"),
+			want:   []string{uuid1 + ",600,30"},
+		},
+		{
+			name:   "compare with checkpoint",
+			args:   append([]string{"compare", "ids-from-file", "-input", ids, "-template2", "{parent}/{id}/", "-checkpoint", filepath.Join(dir, "checkpoint.jsonl")}, bucketFlags...),
+			output: filepath.Join(dir, "checkpoint.txt"),
+			want:   []string{"Total IDs with same files in prod bucket and temp bucket: 1"},
+		},
+		{
+			name:   "compare resumed from checkpoint",
+			args:   append([]string{"compare", "ids-from-file", "-input", ids, "-template2", "{parent}/{id}/", "-checkpoint", filepath.Join(dir, "checkpoint.jsonl"), "-resume"}, bucketFlags...),
+			output: filepath.Join(dir, "resume.txt"),
+			want:   []string{"Resuming from checkpoint '" + filepath.Join(dir, "checkpoint.jsonl") + "': 2 ID(s)", "Total IDs with same files in prod bucket and temp bucket: 1"},
+		},
+		{
+			name:    "checkpoint not overwritten",
+			args:    append([]string{"compare", "ids-from-file", "-input", ids, "-template2", "{parent}/{id}/", "-checkpoint", filepath.Join(dir, "checkpoint.jsonl")}, bucketFlags...),
+			output:  filepath.Join(dir, "fresh.txt"),
+			wantErr: "checkpoint file already has entries",
+		},
+		{
+			name:   "compare with checkpoint overwritten",
+			args:   append([]string{"compare", "ids-from-file", "-input", ids, "-template2", "{parent}/{id}/", "-checkpoint", filepath.Join(dir, "checkpoint.jsonl"), "-overwrite"}, bucketFlags...),
+			output: filepath.Join(dir, "overwrite.txt"),
+			want:   []string{"Total IDs with same files in prod bucket and temp bucket: 1"},
+		},
+		{
+			name:    "errors above max error rate",
+			args:    append([]string{"compare", "ids-from-file", "-input", plainIDs, "-template2", "{parent}/{id}/"}, bucketFlags...),
+			output:  filepath.Join(dir, "errors.txt"),
+			wantErr: "2 of 2 ID(s) not compared (100.0%), above the max error rate of 1.0%",
+		},
+		{
+			name:    "missing input file",
+			args:    append([]string{"compare", "ids-from-file", "-input", filepath.Join(dir, "missing.txt")}, bucketFlags...),
+			output:  filepath.Join(dir, "missing.txt.out"),
+			wantErr: "failed to read file",
+		},
+		{name: "bad max error rate", args: append([]string{"compare", "ids-from-bucket", "-max-error-rate", "2"}, bucketFlags...), wantErr: "-max-error-rate: 2: must be between 0 and 1"},
+		{name: "overwrite with resume", args: append([]string{"compare", "ids-from-bucket", "-checkpoint", "x.jsonl", "-resume", "-overwrite"}, bucketFlags...), wantErr: "-overwrite: only used with -checkpoint, without -resume"},
+		{name: "resume without checkpoint", args: append([]string{"compare", "ids-from-bucket", "-resume"}, bucketFlags...), wantErr: "-resume: only used with -checkpoint"},
+		{name: "bad duration source", args: append([]string{"compare", "ids-from-bucket", "-duration", "exif"}, bucketFlags...), wantErr: `-duration: unknown duration source "exif"`},
+		{name: "mpd without playlists", args: append([]string{"compare", "ids-from-bucket", "-mpd"}, bucketFlags...), wantErr: "-mpd: only used with -playlists"},
+		{name: "bad rule", args: append([]string{"compare", "ids-from-bucket", "-rule", "Low: ratio <"}, bucketFlags...), wantErr: `rule 'Low': term "ratio <"`},
+		{name: "bad bins", args: append([]string{"compare", "ids-from-bucket", "-bins", "10,5"}, bucketFlags...), wantErr: "-bins: boundaries [10 5]"},
+		{name: "missing bucket", args: []string{"list-ids", "-provider", "fs"}, wantErr: "-bucket: required"},
+		{name: "bad template", args: append([]string{"compare", "ids-from-bucket", "-template2", "{date}/"}, bucketFlags...), wantErr: "-template2"},
+		{name: "unknown command", args: []string{"compare", "ids"}, wantErr: "unknown command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.output != "" {
+				args = append(args, "-output", tt.output)
+			}
+			err := run(context.Background(), args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("run() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			out, err := os.ReadFile(tt.output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("output missing %q, got:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRunInterrupted(t *testing.T) {
+	dir := t.TempDir()
+	prod, temp := filepath.Join(dir, "prod"), filepath.Join(dir, "temp")
+	writeFiles(t, prod, "v2/"+uuid1, 60)
+	writeFiles(t, temp, "v4/"+uuid1, 3)
+	ids := filepath.Join(dir, "ids.txt")
+	if err := os.WriteFile(ids, []byte(uuid1+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	checkpoint := filepath.Join(dir, "checkpoint.jsonl")
+	args := []string{"compare", "ids-from-file", "-input", ids, "-provider", "fs", "-bucket", prod, "-root-prefix", "v2/", "-provider2", "fs", "-bucket2", temp, "-root-prefix2", "v4/", "-checkpoint", checkpoint}
+
+	// Ctrl-C before the ID is compared
+	ctx, stop := interruptible(context.Background())
+	defer stop()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	<-ctx.Done()
+	output := filepath.Join(dir, "interrupted.txt")
+	if err := run(ctx, append(args, "-output", output)); err == nil || !strings.Contains(err.Error(), "1 of 1 ID(s) not compared") {
+		t.Fatalf("interrupted run() error = %v, want the ID not compared", err)
+	}
+	if out, _ := os.ReadFile(output); !strings.Contains(string(out), "Total IDs skipped: 1") {
+		t.Errorf("interrupted output missing the skipped ID, got:\n%s", out)
+	}
+
+	// The ID cut off was not checkpointed, so the resumed run compares it
+	output = filepath.Join(dir, "resumed.txt")
+	if err := run(context.Background(), append(args, "-resume", "-output", output)); err != nil {
+		t.Fatalf("resumed run() error = %v", err)
+	}
+	out, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Resuming from checkpoint '" + checkpoint + "': 0 ID(s)", "livestream '" + uuid1 + "': bucket1 '" + prod + "': 60 file(s): bucket2 '" + temp + "': 3 file(s); diff: 57"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("resumed output missing %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunHelp(t *testing.T) {
+	for _, args := range [][]string{nil, {"-h"}, {"list-ids", "-h"}} {
+		if err := run(context.Background(), args); !errors.Is(err, flag.ErrHelp) {
+			t.Errorf("run(%q) error = %v, want %v", args, err, flag.ErrHelp)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"cloud.google.com/go/spanner"
+
+	"common/durations"
+	"common/retry"
+)
+
+func spannerDurations(ctx context.Context, args []string) error {
+	fs := newFlagSet("spanner durations", "Looks up the livestreams in -input (\"<id>,<prod duration>,<temp duration>\" per line)\nin Spanner and writes their recorded duration next to the ones found by the comparison\n(see -duration of the compare commands) and how far they are off.")
+	input := fs.String("input", "input.txt", "file with the file-based durations")
+	output := fs.String("output", "final_output.txt", "file the report is written to, - for stdout")
+	projectID := fs.String("project", "moj-prod", "GCP project of the Spanner instance")
+	instanceID := fs.String("instance", "livestream-instance", "Spanner instance")
+	databaseID := fs.String("database", "production-db", "Spanner database")
+	createdAfter := fs.Int64("created-after", 1734010200000, "only livestreams created after this unix timestamp (ms)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	durationMap, err := durations.ReadFile(*input, log.Default())
+	if err != nil {
+		return err
+	}
+
+	// Create a Spanner client
+	client, err := spanner.NewClient(ctx, durations.DatabaseName(*projectID, *instanceID, *databaseID))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Open the output file for writing
+	outputFile, err := createOutput(*output)
+	if err != nil {
+		return err
+	}
+	defer outputFile.Close()
+
+	var retries retry.Metrics
+	err = durations.Report(ctx, client, durationMap, *createdAfter, outputFile, &retries)
+	if _, writeErr := fmt.Fprintf(outputFile, "Retries: %v\n", retries.Stats()); err == nil {
+		err = writeErr
+	}
+	return err
+}
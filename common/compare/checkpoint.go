@@ -0,0 +1,220 @@
+package compare
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"common/dto"
+)
+
+// Resume says which IDs of an existing checkpoint file are compared again.
+type Resume int
+
+const (
+	Fresh       Resume = iota // start a new checkpoint, refusing to overwrite one with entries
+	Overwrite                 // start over, truncating the checkpoint file
+	ResumeAll                 // skip every ID in the checkpoint, whether it was compared or errored
+	RetryErrors               // skip the IDs that were compared, compare the ones that errored again
+)
+
+// resumes reports whether r takes IDs from an existing checkpoint.
+func (r Resume) resumes() bool {
+	return r == ResumeAll || r == RetryErrors
+}
+
+// ErrCheckpointExists is returned by OpenCheckpoint when a Fresh checkpoint would
+// overwrite the entries of an earlier run.
+var ErrCheckpointExists = errors.New("checkpoint file already has entries")
+
+// checkpointEntry is one line of a checkpoint file: the result of an ID, or why it failed.
+type checkpointEntry struct {
+	ID      string      `json:"id"`
+	Result  *dto.Result `json:"result,omitempty"`
+	Err     string      `json:"error,omitempty"`
+	Outcome string      `json:"outcome,omitempty"` // of a failure, see Outcomes
+}
+
+// failure returns the error of an ID that failed in the run resumed from. It
+// counts under the outcome the ID had then; entries without one as Errored.
+func (e checkpointEntry) failure() error {
+	return &earlierError{outcome: e.Outcome, msg: e.Err}
+}
+
+// earlierError is the failure of an ID in the run resumed from.
+type earlierError struct {
+	outcome string
+	msg     string
+}
+
+func (e *earlierError) Error() string {
+	return fmt.Sprintf("failed in an earlier run: %s", e.msg)
+}
+
+func (e *earlierError) Is(target error) bool {
+	return e.outcome == TimedOut && target == ErrTimeout || e.outcome == Skipped && target == ErrSkipped
+}
+
+// Checkpoint persists the result of each ID to a JSONL file as soon as it is
+// known, so a comparison that crashed or was stopped can be resumed. IDs taken
+// from the checkpoint are reported like the others, but not diffed again (see
+// Options.Diff).
+type Checkpoint struct {
+	mu      sync.Mutex
+	file    *os.File
+	entries map[string]checkpointEntry // last entry per ID
+	resume  Resume
+}
+
+// OpenCheckpoint opens (or creates) the checkpoint file at path. When resuming,
+// the entries already in it are loaded; the last one of an ID wins. A Fresh
+// checkpoint fails with ErrCheckpointExists if the file is not empty.
+func OpenCheckpoint(path string, resume Resume) (*Checkpoint, error) {
+	c := &Checkpoint{entries: make(map[string]checkpointEntry), resume: resume}
+	var size int64
+	switch {
+	case resume.resumes():
+		var err error
+		if size, err = c.load(path); err != nil {
+			return nil, err
+		}
+	case resume == Fresh:
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrCheckpointExists, path)
+		}
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !resume.resumes() {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// Cut off a broken last line, or the next entry would be appended to it
+	if resume.resumes() {
+		if err := file.Truncate(size); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	c.file = file
+	return c, nil
+}
+
+// load reads the entries of the checkpoint file at path, if there is one, and
+// returns the size of its complete entries. A broken last line, left by a crash
+// in the middle of a write, is ignored.
+func (c *Checkpoint) load(path string) (int64, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	var size int64
+	var broken int
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if len(data) == 0 && err == io.EOF {
+			return size, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+		if broken > 0 {
+			return 0, fmt.Errorf("checkpoint %s:%d: invalid entry", path, broken)
+		}
+		// Entries are written with their newline in one go, without it the write was cut short
+		var entry checkpointEntry
+		if err == io.EOF || json.Unmarshal(data, &entry) != nil || entry.ID == "" {
+			broken = line
+			continue
+		}
+		c.entries[entry.ID] = entry
+		size += int64(len(data))
+	}
+}
+
+// Len returns the no. of IDs in the checkpoint.
+func (c *Checkpoint) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// lookup returns the checkpointed outcome of id, and whether it is to be used
+// instead of comparing id again.
+func (c *Checkpoint) lookup(id string) (checkpointEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[id]
+	switch {
+	case !ok || !c.resume.resumes():
+		return entry, false
+	case entry.Result == nil && c.resume == RetryErrors:
+		return entry, false
+	}
+	return entry, true
+}
+
+// Record appends the outcome of comparing rec to the checkpoint file.
+func (c *Checkpoint) Record(rec dto.Record, res dto.Result, err error) error {
+	entry := checkpointEntry{ID: rec.ID}
+	if err != nil {
+		entry.Err, entry.Outcome = err.Error(), outcomeOf(err)
+	} else {
+		res.Rules = nil // applied again on resume
+		entry.Result = &res
+	}
+	line, jsonErr := json.Marshal(entry)
+	if jsonErr != nil {
+		return jsonErr
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[rec.ID] = entry
+	_, writeErr := c.file.Write(append(line, '\n'))
+	return writeErr
+}
+
+// Close closes the checkpoint file.
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
+
+// compareID returns the result of rec from opts.Checkpoint when there is one to
+// resume from, else compares it and checkpoints the outcome. IDs cut off by ctx
+// are not checkpointed, so a resumed run compares them.
+func compareID(ctx context.Context, rec dto.Record, side, side2 Side, opts Options, logger *log.Logger) (dto.Result, error) {
+	if opts.Checkpoint == nil {
+		return compareAcrossBuckets(ctx, rec, side, side2, opts, logger)
+	}
+	if entry, ok := opts.Checkpoint.lookup(rec.ID); ok {
+		if entry.Result == nil {
+			err := entry.failure()
+			logger.Printf("Skipping ID '%s': %v", rec.ID, err)
+			return dto.Result{}, err
+		}
+		return *entry.Result, nil
+	}
+	res, err := compareAcrossBuckets(ctx, rec, side, side2, opts, logger)
+	if ctx.Err() != nil {
+		return res, err
+	}
+	if cpErr := opts.Checkpoint.Record(rec, res, err); cpErr != nil {
+		logger.Printf("Error checkpointing ID '%s': %v", rec.ID, cpErr)
+	}
+	return res, err
+}
@@ -0,0 +1,157 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	side, side2, prod, _ := newSides(
+		map[string]int{"a": 80, "b": 10, "c": 3, "d": 1},
+		map[string]int{"a": 20, "b": 10, "c": 3, "d": 1},
+	)
+	prod.InjectFault(rootPrefix+"c", objectstore.Fault{Err: errors.New("boom")})
+	prod.InjectFault(rootPrefix+"d", objectstore.Fault{Latency: time.Second})
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+
+	// run compares the IDs with the checkpoint opened for resume and returns the
+	// report and the no. of listings of bucket1 it took.
+	run := func(resume Resume) (string, int) {
+		t.Helper()
+		checkpoint, err := OpenCheckpoint(path, resume)
+		if err != nil {
+			t.Fatalf("OpenCheckpoint() error = %v", err)
+		}
+		defer checkpoint.Close()
+		calls := prod.ListCalls()
+		var out bytes.Buffer
+		BucketBasedComparison(context.Background(), side, side2, Options{Workers: 1, Timeout: 50 * time.Millisecond, Checkpoint: checkpoint}, log.New(&out, "", 0))
+		return out.String(), prod.ListCalls() - calls
+	}
+
+	out, calls := run(Fresh)
+	if !strings.Contains(out, "Error checking prefix existence for ID 'c'") || calls != 2 { // the faults fail 'c' and 'd' before their listing
+		t.Fatalf("fresh run: %d listing(s), output:\n%s", calls, out)
+	}
+
+	// Nothing is listed again, but the report is the same
+	out, calls = run(ResumeAll)
+	for _, want := range []string{
+		"a,1200,300", `MoreThan50: ("a")`,
+		"Skipping ID 'c': failed in an earlier run: boom",
+		"Total IDs errored: 1", "Total IDs timed out: 1", "Total IDs skipped: 0",
+		"Failed ID 'd': timed out: failed in an earlier run: " + ErrTimeout.Error(),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("resumed output missing %q, got:\n%s", want, out)
+		}
+	}
+	if calls != 0 {
+		t.Errorf("resume listed bucket1 %d time(s), want 0", calls)
+	}
+
+	// Only the IDs that failed are compared again
+	prod.ClearFaults()
+	out, calls = run(RetryErrors)
+	if calls != 2 || strings.Contains(out, "Skipping ID") || strings.Contains(out, "Error") {
+		t.Errorf("retry: %d listing(s), want 2, output:\n%s", calls, out)
+	}
+	checkpoint, err := OpenCheckpoint(path, ResumeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer checkpoint.Close()
+	if entry, ok := checkpoint.lookup("c"); !ok || entry.Result == nil || entry.Result.Count != 3 {
+		t.Errorf("checkpoint of 'c' = %+v, %v, want the result of the retry", entry, ok)
+	}
+}
+
+func TestOpenCheckpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+		wantErr string
+	}{
+		{name: "missing file"},
+		{name: "entries", content: `{"id":"a","result":{"ID":"a","Count":1}}` + "\n" + `{"id":"b","error":"boom"}` + "\n", want: 2},
+		{name: "last entry wins", content: `{"id":"a","error":"boom"}` + "\n" + `{"id":"a","result":{"ID":"a"}}` + "\n", want: 1},
+		{name: "broken last line", content: `{"id":"a","result":{"ID":"a"}}` + "\n" + `{"id":"b","res`, want: 1},
+		{name: "last line without newline", content: `{"id":"a","result":{"ID":"a"}}` + "\n" + `{"id":"b","error":"boom"}`, want: 1},
+		{name: "broken line", content: `{"id":"a","res` + "\n" + `{"id":"b","error":"boom"}` + "\n", wantErr: "checkpoint.jsonl:1: invalid entry"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			checkpoint, err := OpenCheckpoint(path, ResumeAll)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("OpenCheckpoint() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OpenCheckpoint() error = %v", err)
+			}
+			if got := checkpoint.Len(); got != tt.want {
+				t.Errorf("Len() = %d, want %d", got, tt.want)
+			}
+
+			// Entries appended after the load must be readable again
+			if err := checkpoint.Record(dto.Record{ID: "z"}, dto.Result{}, nil); err != nil {
+				t.Fatal(err)
+			}
+			checkpoint.Close()
+			checkpoint, err = OpenCheckpoint(path, ResumeAll)
+			if err != nil {
+				t.Fatalf("OpenCheckpoint() after Record error = %v", err)
+			}
+			defer checkpoint.Close()
+			if got := checkpoint.Len(); got != tt.want+1 {
+				t.Errorf("Len() after Record = %d, want %d", got, tt.want+1)
+			}
+		})
+	}
+}
+
+func TestOpenCheckpointFresh(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	for _, resume := range []Resume{Fresh, Overwrite} {
+		checkpoint, err := OpenCheckpoint(path, resume)
+		if err != nil {
+			t.Fatalf("OpenCheckpoint(%d) of a new file error = %v", resume, err)
+		}
+		checkpoint.Close()
+	}
+
+	if err := os.WriteFile(path, []byte(`{"id":"a","error":"boom"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenCheckpoint(path, Fresh); !errors.Is(err, ErrCheckpointExists) {
+		t.Fatalf("OpenCheckpoint(Fresh) error = %v, want %v", err, ErrCheckpointExists)
+	}
+	checkpoint, err := OpenCheckpoint(path, Overwrite)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint(Overwrite) error = %v", err)
+	}
+	checkpoint.Close()
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("checkpoint after Overwrite: %v, %v, want it empty", info, err)
+	}
+}
@@ -0,0 +1,441 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+// Side is one of the two buckets being compared.
+type Side struct {
+	Store      objectstore.Store
+	RootPrefix string // IDs live under this prefix
+	Template   string // prefix holding an ID's files, e.g. "CompositePreProcessing/v4/{id}/segments/"; empty means RootPrefix + "{id}"
+
+	// Used when discovering IDs from the bucket, see GetUniqueIDs
+	IDDepth   int            // folders between RootPrefix and the ID folder, e.g. 3 for "<yyyy>/<mm>/<dd>/<id>/"
+	IDPattern *regexp.Regexp // validates the ID folder name; a group named "id" (or the first group) extracts the ID; nil accepts any
+}
+
+// Prefix returns the object prefix holding the files for rec. They are listed
+// under it as a folder, with a trailing "/" added if it lacks one.
+func (s Side) Prefix(rec dto.Record) (string, error) {
+	if s.Template == "" {
+		return fmt.Sprintf("%s%s", s.RootPrefix, rec.ID), nil
+	}
+	return ExpandTemplate(s.Template, rec)
+}
+
+// compareTimeout bounds how long the comparison of one ID may take.
+var compareTimeout = 5 * time.Minute // Adjust timeout as needed
+
+// Options tune a comparison; zero values use the defaults.
+type Options struct {
+	Workers    int              // IDs compared concurrently by BucketBasedComparison, default 64
+	Timeout    time.Duration    // max time for listing and checking one ID, default 5m
+	Threshold  int              // IDs with at least this many more files in bucket1 than bucket2 are reported, default 50
+	Histogram  Histogram        // bins for the summary, default DefaultBoundaries and DefaultRevBoundaries
+	Rules      []Rule           // flag rules, each gives a named bad-ID list; default MoreThan<Threshold>: Diff(Threshold)
+	Verify     bool             // also compare the objects of each ID by checksum, see VerifyID
+	Diff       *DiffWriter      // if set, the object names of each ID are diffed and written to it, see DiffNames
+	Sequence   *regexp.Regexp   // if set, parses segment sequence numbers from object names to find gaps, see AnalyzeSequence
+	Playlists  bool             // also validate the HLS playlists of each ID, see ValidatePlaylists
+	MPD        bool             // with Playlists, also the DASH manifests
+	Durations  DurationProvider // length of the recordings; default the playlist sums when validating playlists, else FixedDuration
+	Checkpoint *Checkpoint      // if set, the outcome of each ID is persisted to it, and IDs it already has are resumed from it
+}
+
+func (o Options) workers() int {
+	if o.Workers <= 0 {
+		return 64
+	}
+	return o.Workers
+}
+
+func (o Options) timeout() time.Duration {
+	if o.Timeout <= 0 {
+		return compareTimeout
+	}
+	return o.Timeout
+}
+
+func (o Options) threshold() int {
+	if o.Threshold <= 0 {
+		return 50
+	}
+	return o.Threshold
+}
+
+func (o Options) durations() DurationProvider {
+	if o.Durations == nil {
+		return FixedDuration{}
+	}
+	return o.Durations
+}
+
+func (o Options) rules() []Rule {
+	if o.Rules == nil {
+		return []Rule{{Name: fmt.Sprintf("MoreThan%d", o.threshold()), Match: Diff(o.threshold())}}
+	}
+	return o.Rules
+}
+
+// CompareNumFilesAcrossBuckets counts the files for rec on both sides concurrently.
+func CompareNumFilesAcrossBuckets(ctx context.Context, rec dto.Record, side, side2 Side, opts Options, logger *log.Logger) (int, int, error) {
+	res, err := compareAcrossBuckets(ctx, rec, side, side2, opts, logger)
+	return res.Count, res.Count2, err
+}
+
+// checkError is an error of one of the checks of an ID; what names the check in the log.
+type checkError struct {
+	what string
+	err  error
+}
+
+func (e *checkError) Error() string { return e.err.Error() }
+func (e *checkError) Unwrap() error { return e.err }
+
+// compareAcrossBuckets lists the files for rec once on each side, concurrently,
+// and runs the checks enabled in opts on them, all within opts.Timeout.
+func compareAcrossBuckets(ctx context.Context, rec dto.Record, side, side2 Side, opts Options, logger *log.Logger) (dto.Result, error) {
+	id := rec.ID
+	for _, s := range []Side{side, side2} {
+		if _, err := s.Prefix(rec); err != nil {
+			logger.Printf("Error building prefix for ID '%s' in bucket '%s': %v", id, s.Store.Name(), err)
+			return dto.Result{}, err
+		}
+	}
+
+	idCtx, cancel := context.WithTimeout(ctx, opts.timeout())
+	defer cancel()
+	res, err := checkID(idCtx, rec, side, side2, opts)
+	if err == nil {
+		return res, nil
+	}
+	var listErr *listingError
+	var checkErr *checkError
+	switch {
+	case errors.Is(idCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil:
+		logger.Printf("Timeout while waiting for data for ID '%s'", id)
+		return dto.Result{}, fmt.Errorf("%w for ID '%s'", ErrTimeout, id)
+	case errors.As(err, &listErr):
+		logger.Printf("Error checking prefix existence for ID '%s' in bucket '%s': %v", id, listErr.bucket, listErr.err)
+	case errors.As(err, &checkErr):
+		logger.Printf("Error %s for ID '%s': %v", checkErr.what, id, checkErr.err)
+	default:
+		logger.Printf("Error comparing ID '%s': %v", id, err)
+	}
+	return dto.Result{}, err
+}
+
+// sideChecks collects what the checks of an ID need from its objects on one side.
+type sideChecks struct {
+	stats     objectstore.Stats
+	sequence  *sequenceVisitor // nil unless Options.Sequence is set
+	playlists *playlistVisitor // nil unless Options.Playlists is set
+	durations durationVisitor
+}
+
+func newSideChecks(opts Options) *sideChecks {
+	c := &sideChecks{durations: newDurationVisitor(opts.durations())}
+	if opts.Sequence != nil {
+		c.sequence = newSequenceVisitor(opts.Sequence)
+	}
+	if opts.Playlists {
+		c.playlists = newPlaylistVisitor(opts.MPD)
+	}
+	return c
+}
+
+func (c *sideChecks) visit(rel string, attrs *objectstore.ObjectAttrs) error {
+	c.stats.Add(attrs.Size)
+	if c.sequence != nil {
+		c.sequence.visit(rel, attrs)
+	}
+	if c.playlists != nil {
+		c.playlists.visit(rel, attrs)
+	}
+	if err := c.durations.visit(rel, attrs); err != nil {
+		return &checkError{what: "getting the duration", err: err}
+	}
+	return nil
+}
+
+// checkID runs the checks of compareAcrossBuckets on a single listing of each side.
+func checkID(ctx context.Context, rec dto.Record, side, side2 Side, opts Options) (dto.Result, error) {
+	res := dto.Result{Record: rec}
+	checks, checks2 := newSideChecks(opts), newSideChecks(opts)
+	err := mergeListings(ctx, rec, side, side2, func(rel string, attrs, attrs2 *objectstore.ObjectAttrs) error {
+		if attrs != nil {
+			if err := checks.visit(rel, attrs); err != nil {
+				return err
+			}
+		}
+		if attrs2 != nil {
+			if err := checks2.visit(rel, attrs2); err != nil {
+				return err
+			}
+		}
+		if opts.Verify {
+			if err := verifyObject(ctx, side.Store, attrs, side2.Store, attrs2, &res.Verification); err != nil {
+				return &checkError{what: "verifying objects", err: err}
+			}
+		}
+		if opts.Diff != nil {
+			if err := opts.Diff.Write(rec.ID, rel, countName(&res.Names, attrs, attrs2)); err != nil {
+				return &checkError{what: "diffing object names", err: err}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.Result{}, err
+	}
+
+	stats, stats2 := checks.stats, checks2.stats
+	res.Count, res.Bytes, res.MinSize, res.MaxSize, res.ZeroBytes = stats.Count, stats.Bytes, stats.MinSize, stats.MaxSize, stats.ZeroBytes
+	res.Count2, res.Bytes2, res.MinSize2, res.MaxSize2, res.ZeroBytes2 = stats2.Count, stats2.Bytes, stats2.MinSize, stats2.MaxSize, stats2.ZeroBytes
+	if opts.Sequence != nil {
+		res.Sequence, res.Sequence2 = checks.sequence.sequence(), checks2.sequence.sequence()
+	}
+	if opts.Playlists {
+		if res.Playlist, err = checks.playlists.validate(ctx, side.Store); err == nil {
+			res.Playlist2, err = checks2.playlists.validate(ctx, side2.Store)
+		}
+		if err != nil {
+			return dto.Result{}, &checkError{what: "validating playlists", err: err}
+		}
+	}
+	if err := setDurations(ctx, &res, side, side2, checks.durations, checks2.durations, opts); err != nil {
+		return dto.Result{}, &checkError{what: "getting the duration", err: err}
+	}
+	return res, nil
+}
+
+// flagResult applies the rules to res, recording the names of the rules it tripped
+// in res.Rules and adding its ID to their lists in badIds.
+func flagResult(res *dto.Result, rules []Rule, badIds map[string][]string) {
+	for _, rule := range rules {
+		if rule.Match(*res) {
+			res.Rules = append(res.Rules, rule.Name)
+			badIds[rule.Name] = append(badIds[rule.Name], res.ID)
+		}
+	}
+}
+
+// classifyFindings returns the size findings for res and those of the optional
+// checks (verification, segment sequence) enabled in opts.
+func classifyFindings(res dto.Result, opts Options) []string {
+	found := classifySizes(res)
+	if opts.Verify {
+		found = append(found, classifyVerification(res)...)
+	}
+	if opts.Sequence != nil {
+		found = append(found, classifySequence(res)...)
+	}
+	if opts.Playlists {
+		found = append(found, classifyPlaylist(res)...)
+	}
+	return found
+}
+
+// logFindings logs the details of the optional checks enabled in opts for res, if it has any problem.
+func logFindings(logger *log.Logger, res dto.Result, opts Options, bucket, bucket2 string) {
+	if opts.Verify && !allIdentical(res.Verification) {
+		logVerification(logger, res, bucket, bucket2)
+	}
+	if opts.Diff != nil && res.Names.OnlyInSource+res.Names.OnlyInTarget > 0 {
+		logNames(logger, res, bucket, bucket2)
+	}
+	if opts.Sequence != nil {
+		logSequence(logger, res, bucket, bucket2)
+	}
+	if opts.Playlists {
+		logPlaylist(logger, res, bucket, bucket2)
+	}
+}
+
+// printFindings logs the summary of the findings, see classifyFindings.
+func printFindings(logger *log.Logger, opts Options, findings dto.Counts) {
+	printSizes(logger, findings)
+	if opts.Verify {
+		printVerification(logger, findings)
+	}
+	if opts.Sequence != nil {
+		printSequence(logger, findings)
+	}
+	if opts.Playlists {
+		printPlaylist(logger, findings)
+	}
+}
+
+// playlistDurations reports whether the durations are the sums of the playlists
+// the comparison validates: without Options.Durations, or with a ManifestDuration
+// over the same playlists, which then need not be read again.
+func (o Options) playlistDurations() bool {
+	if !o.Playlists {
+		return false
+	}
+	m, ok := o.Durations.(ManifestDuration)
+	return o.Durations == nil || ok && m.MPD == o.MPD
+}
+
+// setDurations sets res.Duration and res.Duration2 from the duration visitors of
+// both sides. The sums of the validated playlists are used where they were found
+// and the durations come from them, see Options.playlistDurations.
+func setDurations(ctx context.Context, res *dto.Result, side, side2 Side, durations, durations2 durationVisitor, opts Options) error {
+	var err error
+	if opts.playlistDurations() && res.Playlist.Playlists > 0 {
+		res.Duration = res.Playlist.Duration
+	} else if res.Duration, err = durations.duration(ctx, side, res.Record, res.Count); err != nil {
+		return err
+	}
+	if opts.playlistDurations() && res.Playlist2.Playlists > 0 {
+		res.Duration2 = res.Playlist2.Duration
+	} else if res.Duration2, err = durations2.duration(ctx, side2, res.Record, res.Count2); err != nil {
+		return err
+	}
+	return nil
+}
+
+// printBadIDs logs the IDs flagged by each rule, in the order of the rules.
+func printBadIDs(logger *log.Logger, rules []Rule, badIds map[string][]string) {
+	for _, rule := range rules {
+		logger.Printf("%s: %s\n", rule.Name, quoteIDs(badIds[rule.Name]))
+	}
+}
+
+// FileBasedComparison compares the no. of files per ID across both sides for the IDs listed in path (see ReadRecords).
+// It fails only when the IDs cannot be read, the IDs that fail are in the Outcomes.
+func FileBasedComparison(ctx context.Context, side, side2 Side, path string, opts Options, logger *log.Logger) (Outcomes, error) {
+	bucket, bucket2 := side.Store.Name(), side2.Store.Name()
+
+	// Read the file containing IDs
+	records, err := ReadRecords(path)
+	if err != nil {
+		return Outcomes{}, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	cntLess, cntEq, cntMore := 0, 0, 0
+	cnt := dto.Counts{}
+	findings := dto.Counts{}
+	badIds := make(map[string][]string)
+	var outcomes Outcomes
+	for _, rec := range records {
+		id := rec.ID
+		res, err := compareID(ctx, rec, side, side2, opts, logger)
+		outcomes.add(id, err)
+		if err != nil {
+			continue
+		}
+		numFiles, numFiles2 := res.Count, res.Count2
+
+		bin := opts.Histogram.Classify(numFiles, numFiles2)
+		cnt[bin] = append(cnt[bin], id)
+		flagResult(&res, opts.rules(), badIds)
+		for _, name := range classifyFindings(res, opts) {
+			findings[name] = append(findings[name], id)
+		}
+		if numFiles < numFiles2 {
+			cntLess++
+		} else if numFiles == numFiles2 {
+			cntEq++
+		} else {
+			logger.Printf("livestream '%s': bucket1 '%s': %d file(s): bucket2 '%s': %d file(s); diff: %d\n", id, bucket, numFiles, bucket2, numFiles2, numFiles-numFiles2)
+			logger.Println("--------------------------------------")
+			cntMore++
+		}
+		if SizeMismatch()(res) {
+			logSizes(logger, res, bucket, bucket2)
+		}
+		logFindings(logger, res, opts, bucket, bucket2)
+	}
+	logger.Printf("Total IDs in temp bucket: %d\n", len(records))
+	printOutcomes(logger, outcomes)
+	logger.Printf("Total IDs with less files in prod bucket than temp bucket: %d\n", cntLess)
+	logger.Printf("Total IDs with same files in prod bucket and temp bucket: %d\n", cntEq)
+	logger.Printf("Total IDs with more files in prod bucket than temp bucket: %d\n", cntMore)
+	printHistogram(logger, opts.Histogram, cnt)
+	printFindings(logger, opts, findings)
+	printBadIDs(logger, opts.rules(), badIds)
+	return outcomes, nil
+}
+
+func calculateCounts(ctx context.Context, side, side2 Side, rec dto.Record, opts Options, logger *log.Logger, cnt, findings dto.Counts, outcomes *Outcomes, mutex *sync.Mutex, badIds map[string][]string) {
+	res, err := compareID(ctx, rec, side, side2, opts, logger)
+
+	mutex.Lock()
+	defer mutex.Unlock() // Ensure safe access to shared state
+
+	outcomes.add(rec.ID, err)
+	if err != nil {
+		return
+	}
+
+	bin := opts.Histogram.Classify(res.Count, res.Count2)
+	cnt[bin] = append(cnt[bin], res.ID)
+
+	flagResult(&res, opts.rules(), badIds)
+	if len(res.Rules) > 0 {
+		logger.Printf("%s,%.0f,%.0f\n", res.ID, res.Duration, res.Duration2) // total duration in sec, see Options.Durations
+	}
+	logFindings(logger, res, opts, side.Store.Name(), side2.Store.Name())
+	for _, name := range classifyFindings(res, opts) {
+		findings[name] = append(findings[name], res.ID)
+	}
+}
+
+func worker(ctx context.Context, side, side2 Side, opts Options, logger *log.Logger, cnt, findings dto.Counts, outcomes *Outcomes, mutex *sync.Mutex, jobs <-chan dto.Record, wg *sync.WaitGroup, badIds map[string][]string) {
+	defer wg.Done()
+	for rec := range jobs {
+		calculateCounts(ctx, side, side2, rec, opts, logger, cnt, findings, outcomes, mutex, badIds)
+	}
+}
+
+// BucketBasedComparison compares the no. of files per ID across both sides for every ID found in side2.
+// It fails only when the IDs cannot be listed, the IDs that fail are in the Outcomes.
+func BucketBasedComparison(ctx context.Context, side, side2 Side, opts Options, logger *log.Logger) (Outcomes, error) {
+	ids, skipped, err := GetUniqueIDs(ctx, side2)
+	if err != nil {
+		return Outcomes{}, fmt.Errorf("failed to retrieve IDs from temp bucket: %w", err)
+	}
+	for _, prefix := range skipped {
+		logger.Printf("Skipping prefix '%s': no ID matching the pattern\n", prefix)
+	}
+
+	jobs := make(chan dto.Record, len(ids))
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	cnt := dto.Counts{}                 // IDs per histogram bin, shared by the workers
+	findings := dto.Counts{}            // IDs per size or verification finding
+	badIds := make(map[string][]string) // IDs per flag rule
+	var outcomes Outcomes               // how each ID ended
+
+	for _, rec := range ids {
+		jobs <- rec
+	}
+	close(jobs)
+
+	for w := 1; w <= opts.workers(); w++ {
+		wg.Add(1)
+		go worker(ctx, side, side2, opts, logger, cnt, findings, &outcomes, &mutex, jobs, &wg, badIds)
+	}
+
+	wg.Wait()
+
+	logger.Printf("Total IDs in temp bucket: %d\n", len(ids))
+	logger.Printf("Total prefixes skipped in temp bucket: %d\n", len(skipped))
+	printOutcomes(logger, outcomes)
+
+	printHistogram(logger, opts.Histogram, cnt)
+	printFindings(logger, opts, findings)
+	printBadIDs(logger, opts.rules(), badIds)
+	return outcomes, nil
+}
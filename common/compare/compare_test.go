@@ -0,0 +1,297 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+const (
+	rootPrefix  = "CompositePreProcessing/v2/"
+	rootPrefix2 = "CompositePreProcessing/v4/"
+)
+
+// putFiles adds n segment files for id under rootPrefix.
+func putFiles(m *objectstore.MemoryStore, rootPrefix, id string, n int) {
+	for i := 0; i < n; i++ {
+		m.Put(fmt.Sprintf("%s%s/segment_%05d.ts", rootPrefix, id, i), []byte("x"))
+	}
+}
+
+func newSides(files, files2 map[string]int) (Side, Side, *objectstore.MemoryStore, *objectstore.MemoryStore) {
+	prod := objectstore.NewMemoryStore("prod")
+	temp := objectstore.NewMemoryStore("temp")
+	for id, n := range files {
+		putFiles(prod, rootPrefix, id, n)
+	}
+	for id, n := range files2 {
+		putFiles(temp, rootPrefix2, id, n)
+	}
+	return Side{Store: prod, RootPrefix: rootPrefix}, Side{Store: temp, RootPrefix: rootPrefix2}, prod, temp
+}
+
+func TestCompareNumFilesAcrossBuckets(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name          string
+		files, files2 int
+		fault, fault2 *objectstore.Fault
+		want, want2   int
+		wantErr       error
+		wantTimeout   bool
+	}{
+		{name: "equal", files: 10, files2: 10, want: 10, want2: 10},
+		{name: "more in bucket1", files: 70, files2: 10, want: 70, want2: 10},
+		{name: "more in bucket2", files: 3, files2: 9, want: 3, want2: 9},
+		{name: "missing in both", want: 0, want2: 0},
+		{name: "error in bucket1", files: 1, files2: 1, fault: &objectstore.Fault{Err: errBoom}, wantErr: errBoom},
+		{name: "error in bucket2", files: 1, files2: 1, fault2: &objectstore.Fault{Err: errBoom}, wantErr: errBoom},
+		{name: "slow but within timeout", files: 2, files2: 2, fault: &objectstore.Fault{Latency: 5 * time.Millisecond}, want: 2, want2: 2},
+		{name: "timeout", files: 2, files2: 2, fault2: &objectstore.Fault{Latency: time.Second}, wantTimeout: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			side, side2, prod, temp := newSides(map[string]int{"id": tt.files}, map[string]int{"id": tt.files2})
+			if tt.fault != nil {
+				prod.InjectFault(rootPrefix, *tt.fault)
+			}
+			if tt.fault2 != nil {
+				temp.InjectFault(rootPrefix2, *tt.fault2)
+			}
+			var out bytes.Buffer
+			logger := log.New(&out, "", 0)
+
+			got, got2, err := CompareNumFilesAcrossBuckets(context.Background(), dto.Record{ID: "id"}, side, side2, Options{Timeout: 50 * time.Millisecond}, logger)
+			switch {
+			case tt.wantTimeout:
+				if err == nil || !strings.Contains(err.Error(), "timeout") {
+					t.Fatalf("error = %v, want timeout", err)
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if !strings.Contains(out.String(), "Error checking prefix existence for ID 'id'") {
+					t.Errorf("error not logged, got %q", out.String())
+				}
+			default:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got != tt.want || got2 != tt.want2 {
+					t.Errorf("got (%d, %d), want (%d, %d)", got, got2, tt.want, tt.want2)
+				}
+			}
+		})
+	}
+}
+
+func TestCompareAcrossBucketsAllChecks(t *testing.T) {
+	opts := Options{
+		Timeout:   50 * time.Millisecond,
+		Verify:    true,
+		Diff:      NewDiffWriter(io.Discard, true),
+		Sequence:  DefaultSequencePattern,
+		Playlists: true,
+		MPD:       true,
+		Durations: TimestampDuration{},
+	}
+
+	t.Run("lists each side once", func(t *testing.T) {
+		side, side2, prod, temp := newSides(map[string]int{"id": 5}, map[string]int{"id": 4})
+		var out bytes.Buffer
+		res, err := compareAcrossBuckets(context.Background(), dto.Record{ID: "id"}, side, side2, opts, log.New(&out, "", 0))
+		if err != nil {
+			t.Fatalf("unexpected error: %v\n%s", err, out.String())
+		}
+		if res.Count != 5 || res.Count2 != 4 || res.Names.OnlyInSource != 1 || res.Verification.MissingInTarget != 1 {
+			t.Errorf("got counts (%d, %d), names %+v, verification %+v", res.Count, res.Count2, res.Names, res.Verification)
+		}
+		if prod.ListCalls() != 1 || temp.ListCalls() != 1 {
+			t.Errorf("list calls = (%d, %d), want (1, 1)", prod.ListCalls(), temp.ListCalls())
+		}
+	})
+
+	t.Run("ID that is a prefix of another", func(t *testing.T) {
+		side, side2, _, _ := newSides(map[string]int{"abc": 3, "abc0": 2}, map[string]int{"abc": 3, "abc0": 4})
+		var out bytes.Buffer
+		res, err := compareAcrossBuckets(context.Background(), dto.Record{ID: "abc"}, side, side2, opts, log.New(&out, "", 0))
+		if err != nil {
+			t.Fatalf("unexpected error: %v\n%s", err, out.String())
+		}
+		if res.Count != 3 || res.Count2 != 3 || res.Names.InBoth != 3 || res.Verification.Identical != 3 {
+			t.Errorf("got counts (%d, %d), names %+v, verification %+v", res.Count, res.Count2, res.Names, res.Verification)
+		}
+	})
+
+	t.Run("timeout covers the checks", func(t *testing.T) {
+		side, side2, _, temp := newSides(map[string]int{"id": 2}, map[string]int{"id": 2})
+		// Only reading the playlist is slow, not the listing
+		temp.Put(rootPrefix2+"id/index.m3u8", []byte("#EXTM3U\n"))
+		temp.InjectFault(rootPrefix2+"id/index.m3u8", objectstore.Fault{Latency: time.Second})
+		var out bytes.Buffer
+		_, err := compareAcrossBuckets(context.Background(), dto.Record{ID: "id"}, side, side2, opts, log.New(&out, "", 0))
+		if !errors.Is(err, ErrTimeout) {
+			t.Fatalf("error = %v, want timeout", err)
+		}
+		if !strings.Contains(out.String(), "Timeout while waiting for data for ID 'id'") {
+			t.Errorf("timeout not logged, got %q", out.String())
+		}
+	})
+}
+
+func TestFileBasedComparison(t *testing.T) {
+	side, side2, prod, _ := newSides(
+		map[string]int{"less": 1, "equal": 5, "more": 60, "broken": 1},
+		map[string]int{"less": 2, "equal": 5, "more": 1, "broken": 1},
+	)
+	prod.InjectFault(rootPrefix+"broken", objectstore.Fault{Err: errors.New("boom")})
+
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("less\n\nequal\n more \nbroken\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	FileBasedComparison(context.Background(), side, side2, path, Options{}, log.New(&out, "", 0))
+
+	for _, want := range []string{
+		"livestream 'more': bucket1 'prod': 60 file(s): bucket2 'temp': 1 file(s); diff: 59",
+		"Total IDs in temp bucket: 4",
+		"Total IDs compared: 3",
+		"Total IDs errored: 1",
+		"Failed ID 'broken': errored: boom",
+		"Total IDs with less files in prod bucket than temp bucket: 1",
+		"Total IDs with same files in prod bucket and temp bucket: 1",
+		"Total IDs with more files in prod bucket than temp bucket: 1",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestFileBasedComparisonTemplates(t *testing.T) {
+	prod := objectstore.NewMemoryStore("prod")
+	temp := objectstore.NewMemoryStore("temp")
+	putFiles(prod, rootPrefix, "a", 4)
+	putFiles(prod, rootPrefix, "b", 70)
+	putFiles(temp, "2024/12/01/", "a", 4)
+	putFiles(temp, "2024/12/02/", "b", 3)
+
+	path := filepath.Join(t.TempDir(), "ids.csv")
+	if err := os.WriteFile(path, []byte("id,date\na,2024/12/01\nb,2024/12/02\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	side := Side{Store: prod, RootPrefix: rootPrefix}
+	side2 := Side{Store: temp, Template: "{date}/{id}/"}
+	var out bytes.Buffer
+	FileBasedComparison(context.Background(), side, side2, path, Options{}, log.New(&out, "", 0))
+
+	for _, want := range []string{
+		"livestream 'b': bucket1 'prod': 70 file(s): bucket2 'temp': 3 file(s); diff: 67",
+		"Total IDs with same files in prod bucket and temp bucket: 1",
+		"Total IDs with more files in prod bucket than temp bucket: 1",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestBucketBasedComparison(t *testing.T) {
+	files := map[string]int{}
+	files2 := map[string]int{}
+	for i := 0; i < 200; i++ {
+		id := fmt.Sprintf("id-%03d", i)
+		files[id], files2[id] = 10, 10
+	}
+	files["id-007"], files2["id-007"] = 80, 20 // 60 more in prod
+	files["id-042"], files2["id-042"] = 59, 10 // 49 more in prod, below the threshold
+	files["id-100"], files2["id-100"] = 1, 90
+
+	side, side2, _, temp := newSides(files, files2)
+	temp.PageSize = 7 // force the ID listing to paginate
+
+	var out bytes.Buffer
+	BucketBasedComparison(context.Background(), side, side2, Options{}, log.New(&out, "", 0))
+
+	for _, want := range []string{
+		"id-007,1200,300",
+		"Total IDs in temp bucket: 200",
+		`MoreThan50: ("id-007")`,
+		"Total IDs with same files in prod bucket and temp bucket: 197",
+		"Total IDs with 51-100 less files in prod bucket than temp bucket: 1",
+		"Total IDs with more than 500 more files in prod bucket than temp bucket: 0",
+		`Diff41To50: ("id-042")`,
+		`Diff51To60: ("id-007")`,
+		`Diff51To100Rev: ("id-100")`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "id-042,") || strings.Contains(out.String(), "id-100,") {
+		t.Errorf("unexpected ID flagged, got:\n%s", out.String())
+	}
+}
+
+func TestBucketBasedComparisonOptions(t *testing.T) {
+	side, side2, _, _ := newSides(
+		map[string]int{"a": 80, "b": 59, "c": 10},
+		map[string]int{"a": 20, "b": 10, "c": 10},
+	)
+
+	var out bytes.Buffer
+	BucketBasedComparison(context.Background(), side, side2, Options{Workers: 1, Threshold: 40}, log.New(&out, "", 0))
+
+	if want := `MoreThan40: ("a", "b")`; !strings.Contains(out.String(), want) {
+		t.Errorf("output missing %q, got:\n%s", want, out.String())
+	}
+}
+
+func TestComparisonSizes(t *testing.T) {
+	side, side2, prod, temp := newSides(map[string]int{"same": 3, "truncated": 3}, map[string]int{"same": 3, "truncated": 2})
+	temp.Put(rootPrefix2+"truncated/segment_00002.ts", nil)
+	prod.Put(rootPrefix+"empty/segment_00000.ts", nil)
+	temp.Put(rootPrefix2+"empty/segment_00000.ts", nil)
+
+	var out bytes.Buffer
+	BucketBasedComparison(context.Background(), side, side2, Options{}, log.New(&out, "", 0))
+
+	for _, want := range []string{
+		"Total IDs with same files but different size in prod bucket and temp bucket: 1",
+		"Total IDs with zero-byte files in prod bucket: 1",
+		"Total IDs with zero-byte files in temp bucket: 2",
+		`SizeMismatch: ("truncated")`,
+		`ZeroBytes: ("empty")`,
+		`ZeroBytes2: ("empty", "truncated")`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q, got:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("same\ntruncated\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	FileBasedComparison(context.Background(), side, side2, path, Options{}, log.New(&out, "", 0))
+	want := "livestream 'truncated': bucket1 'prod': 3 byte(s) (min 1, max 1, 0 empty): bucket2 'temp': 2 byte(s) (min 0, max 1, 1 empty)"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("output missing %q, got:\n%s", want, out.String())
+	}
+}
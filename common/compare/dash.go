@@ -0,0 +1,257 @@
+package compare
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mpd is the subset of a DASH manifest needed to list its segments.
+type mpd struct {
+	Duration string      `xml:"mediaPresentationDuration,attr"`
+	BaseURL  string      `xml:"BaseURL"`
+	Periods  []mpdPeriod `xml:"Period"`
+}
+
+type mpdPeriod struct {
+	Duration       string          `xml:"duration,attr"`
+	BaseURL        string          `xml:"BaseURL"`
+	AdaptationSets []mpdAdaptation `xml:"AdaptationSet"`
+}
+
+type mpdAdaptation struct {
+	BaseURL         string              `xml:"BaseURL"`
+	SegmentTemplate *mpdTemplate        `xml:"SegmentTemplate"`
+	Representations []mpdRepresentation `xml:"Representation"`
+}
+
+type mpdRepresentation struct {
+	ID              string       `xml:"id,attr"`
+	Bandwidth       string       `xml:"bandwidth,attr"`
+	BaseURL         string       `xml:"BaseURL"`
+	SegmentTemplate *mpdTemplate `xml:"SegmentTemplate"`
+	SegmentList     *mpdList     `xml:"SegmentList"`
+}
+
+type mpdTemplate struct {
+	Media          string   `xml:"media,attr"`
+	Initialization string   `xml:"initialization,attr"`
+	StartNumber    *int64   `xml:"startNumber,attr"`
+	Timescale      int64    `xml:"timescale,attr"`
+	Duration       int64    `xml:"duration,attr"`
+	Timeline       []mpdSeg `xml:"SegmentTimeline>S"`
+}
+
+type mpdSeg struct {
+	T *int64 `xml:"t,attr"`
+	D int64  `xml:"d,attr"`
+	R int64  `xml:"r,attr"`
+}
+
+type mpdList struct {
+	Timescale      int64 `xml:"timescale,attr"`
+	Duration       int64 `xml:"duration,attr"`
+	Initialization struct {
+		SourceURL string `xml:"sourceURL,attr"`
+	} `xml:"Initialization"`
+	URLs []struct {
+		Media string `xml:"media,attr"`
+	} `xml:"SegmentURL"`
+}
+
+// maxMPDSegments caps the no. of segments the templates of a manifest expand to,
+// so a bogus repeat count or duration cannot run the comparison out of memory.
+var maxMPDSegments = 1 << 20
+
+// parseMPD parses a DASH manifest into one rendition per representation. Segments
+// come from a SegmentList or a SegmentTemplate, with a SegmentTimeline or a fixed
+// duration (then the period or presentation duration gives their number).
+func parseMPD(r io.Reader) ([]rendition, error) {
+	var m mpd
+	if err := xml.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	var renditions []rendition
+	segments := 0
+	for _, period := range m.Periods {
+		periodDuration := period.Duration
+		if periodDuration == "" {
+			periodDuration = m.Duration
+		}
+		for _, set := range period.AdaptationSets {
+			for _, rep := range set.Representations {
+				base := joinBaseURLs(m.BaseURL, period.BaseURL, set.BaseURL, rep.BaseURL)
+				var refs rendition
+				var err error
+				switch {
+				case rep.SegmentList != nil:
+					refs = listSegments(rep.SegmentList)
+				case rep.SegmentTemplate != nil:
+					refs, err = templateSegments(rep.SegmentTemplate, rep, periodDuration, maxMPDSegments-segments)
+				case set.SegmentTemplate != nil:
+					refs, err = templateSegments(set.SegmentTemplate, rep, periodDuration, maxMPDSegments-segments)
+				case base != "":
+					refs = rendition{{uri: base}} // single segment representation
+					base = ""
+				}
+				if err != nil {
+					return nil, fmt.Errorf("representation '%s': %w", rep.ID, err)
+				}
+				for i := range refs {
+					refs[i].uri = resolveBaseURL(base, refs[i].uri)
+				}
+				segments += len(refs)
+				renditions = append(renditions, refs)
+			}
+		}
+	}
+	return renditions, nil
+}
+
+// joinBaseURLs resolves the nested BaseURL elements, an absolute one replaces the ones above it.
+func joinBaseURLs(bases ...string) string {
+	joined := ""
+	for _, base := range bases {
+		joined = resolveBaseURL(joined, strings.TrimSpace(base))
+	}
+	return joined
+}
+
+func resolveBaseURL(base, uri string) string {
+	if base == "" || uri == "" || isAbsoluteURL(uri) || strings.HasPrefix(uri, "/") {
+		if uri == "" {
+			return base
+		}
+		return uri
+	}
+	if strings.HasSuffix(base, "/") {
+		return base + uri
+	}
+	return path.Join(path.Dir(base), uri)
+}
+
+func listSegments(list *mpdList) rendition {
+	var refs rendition
+	if list.Initialization.SourceURL != "" {
+		refs = append(refs, playlistRef{uri: list.Initialization.SourceURL})
+	}
+	for _, url := range list.URLs {
+		refs = append(refs, playlistRef{uri: url.Media, duration: seconds(list.Duration, list.Timescale)})
+	}
+	return refs
+}
+
+// templateSegments expands a SegmentTemplate into at most limit segments.
+func templateSegments(tmpl *mpdTemplate, rep mpdRepresentation, periodDuration string, limit int) (rendition, error) {
+	errTooMany := fmt.Errorf("manifest expands to more than %d segments", maxMPDSegments)
+	var refs rendition
+	if tmpl.Initialization != "" {
+		refs = append(refs, playlistRef{uri: expandMPDTemplate(tmpl.Initialization, rep, 0, 0)})
+	}
+	if tmpl.Media == "" {
+		return refs, nil
+	}
+	number := int64(1)
+	if tmpl.StartNumber != nil {
+		number = *tmpl.StartNumber
+	}
+
+	if len(tmpl.Timeline) > 0 {
+		n := int64(0)
+		for _, s := range tmpl.Timeline {
+			if n += max(s.R, 0) + 1; max(s.R, 0) >= int64(limit) || n > int64(limit) {
+				return nil, errTooMany
+			}
+		}
+		var t int64
+		for _, s := range tmpl.Timeline {
+			if s.T != nil {
+				t = *s.T
+			}
+			// A negative repeat runs to the end of the period, which is not known here
+			for i := int64(0); i <= max(s.R, 0); i++ {
+				refs = append(refs, playlistRef{uri: expandMPDTemplate(tmpl.Media, rep, number, t), duration: seconds(s.D, tmpl.Timescale)})
+				number++
+				t += s.D
+			}
+		}
+		return refs, nil
+	}
+
+	if tmpl.Duration <= 0 {
+		return nil, fmt.Errorf("SegmentTemplate without SegmentTimeline or duration")
+	}
+	total, err := parseISODuration(periodDuration)
+	if err != nil {
+		return nil, err
+	}
+	segment := seconds(tmpl.Duration, tmpl.Timescale)
+	if total/segment > float64(limit) {
+		return nil, errTooMany
+	}
+	count := int64(math.Ceil(total/segment - 1e-9))
+	for i := int64(0); i < count; i++ {
+		d := math.Min(segment, total-float64(i)*segment) // the last one may be shorter
+		refs = append(refs, playlistRef{uri: expandMPDTemplate(tmpl.Media, rep, number+i, i*tmpl.Duration), duration: d})
+	}
+	return refs, nil
+}
+
+// mpdIdentifier matches the identifiers of a SegmentTemplate, e.g. $Number%05d$.
+var mpdIdentifier = regexp.MustCompile(`\$(RepresentationID|Number|Time|Bandwidth|)(%0(\d+)d)?\$`)
+
+func expandMPDTemplate(tmpl string, rep mpdRepresentation, number, t int64) string {
+	return mpdIdentifier.ReplaceAllStringFunc(tmpl, func(id string) string {
+		match := mpdIdentifier.FindStringSubmatch(id)
+		var value string
+		switch match[1] {
+		case "":
+			return "$"
+		case "RepresentationID":
+			return rep.ID
+		case "Bandwidth":
+			value = rep.Bandwidth
+		case "Number":
+			value = strconv.FormatInt(number, 10)
+		case "Time":
+			value = strconv.FormatInt(t, 10)
+		}
+		if width, _ := strconv.Atoi(match[3]); len(value) < width {
+			value = strings.Repeat("0", width-len(value)) + value
+		}
+		return value
+	})
+}
+
+// seconds converts a duration in timescale units, timescale defaults to 1.
+func seconds(d, timescale int64) float64 {
+	if timescale <= 0 {
+		timescale = 1
+	}
+	return float64(d) / float64(timescale)
+}
+
+// isoDuration matches the durations of a manifest, e.g. PT1H2M3.5S.
+var isoDuration = regexp.MustCompile(`^P(?:(\d+(?:\.\d+)?)D)?(?:T(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseISODuration parses an ISO 8601 duration (days and time only) into sec.
+func parseISODuration(s string) (float64, error) {
+	match := isoDuration.FindStringSubmatch(s)
+	if match == nil || s == "P" || s == "PT" {
+		return 0, fmt.Errorf("invalid duration '%s'", s)
+	}
+	var total float64
+	for i, unit := range []float64{86400, 3600, 60, 1} {
+		if match[i+1] != "" {
+			v, _ := strconv.ParseFloat(match[i+1], 64)
+			total += v * unit
+		}
+	}
+	return total, nil
+}
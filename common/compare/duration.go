@@ -0,0 +1,225 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+// SegmentDuration is the length of one recording segment, see FixedDuration.
+const SegmentDuration = 15 * time.Second
+
+// DurationProvider works out the length of the recording of an ID in one bucket.
+type DurationProvider interface {
+	// Duration returns the length (in sec) of the recording of rec on side, which has count files.
+	Duration(ctx context.Context, side Side, rec dto.Record, count int) (float64, error)
+}
+
+// FixedDuration assumes every file is a segment of the same length, i.e. duration = no. of files * Segment.
+type FixedDuration struct {
+	Segment time.Duration // default SegmentDuration
+}
+
+func (d FixedDuration) Duration(ctx context.Context, side Side, rec dto.Record, count int) (float64, error) {
+	segment := d.Segment
+	if segment <= 0 {
+		segment = SegmentDuration
+	}
+	return float64(count) * segment.Seconds(), nil
+}
+
+// listingDuration is a DurationProvider that works the duration out from the
+// objects of an ID, so a comparison can feed it the listing its checks share
+// instead of listing the ID again.
+type listingDuration interface {
+	DurationProvider
+	newVisitor() durationVisitor
+}
+
+// durationVisitor collects what a DurationProvider needs from the objects of an ID on one side.
+type durationVisitor interface {
+	objectVisitor
+	// duration returns the length (in sec) of the recording of rec on side, which has count files.
+	duration(ctx context.Context, side Side, rec dto.Record, count int) (float64, error)
+}
+
+// newDurationVisitor returns the visitor of p; one that ignores the objects for a
+// provider that does not need them.
+func newDurationVisitor(p DurationProvider) durationVisitor {
+	if l, ok := p.(listingDuration); ok {
+		return l.newVisitor()
+	}
+	return providerVisitor{p}
+}
+
+// providerVisitor asks a DurationProvider that needs no listing for the duration.
+type providerVisitor struct {
+	DurationProvider
+}
+
+func (providerVisitor) visit(rel string, attrs *objectstore.ObjectAttrs) error { return nil }
+
+func (v providerVisitor) duration(ctx context.Context, side Side, rec dto.Record, count int) (float64, error) {
+	return v.Duration(ctx, side, rec, count)
+}
+
+// listDuration lists rec on side for the duration of a listingDuration.
+func listDuration(ctx context.Context, p listingDuration, side Side, rec dto.Record, count int) (float64, error) {
+	v := p.newVisitor()
+	if err := visitSide(ctx, side, rec, v); err != nil {
+		return 0, err
+	}
+	return v.duration(ctx, side, rec, count)
+}
+
+// ManifestDuration sums the segment durations in the playlists (#EXTINF), see ValidatePlaylists.
+// IDs without a playlist use Fallback (default FixedDuration).
+type ManifestDuration struct {
+	MPD      bool // also DASH manifests
+	Fallback DurationProvider
+}
+
+func (d ManifestDuration) Duration(ctx context.Context, side Side, rec dto.Record, count int) (float64, error) {
+	return listDuration(ctx, d, side, rec, count)
+}
+
+func (d ManifestDuration) newVisitor() durationVisitor {
+	return &manifestVisitor{playlists: newPlaylistVisitor(d.MPD), fallback: newDurationVisitor(fallback(d.Fallback))}
+}
+
+type manifestVisitor struct {
+	playlists *playlistVisitor
+	fallback  durationVisitor
+}
+
+func (v *manifestVisitor) visit(rel string, attrs *objectstore.ObjectAttrs) error {
+	return visitors{v.playlists, v.fallback}.visit(rel, attrs)
+}
+
+func (v *manifestVisitor) duration(ctx context.Context, side Side, rec dto.Record, count int) (float64, error) {
+	p, err := v.playlists.validate(ctx, side.Store)
+	if err != nil {
+		return 0, err
+	}
+	if p.Playlists == 0 {
+		return v.fallback.duration(ctx, side, rec, count)
+	}
+	return p.Duration, nil
+}
+
+// MetadataDuration sums a custom metadata value (in sec) set on each segment, e.g. "duration"
+// (OCI returns opc-meta-* keys in lower case). Objects without it, such as playlists, are skipped;
+// IDs where no object has it use Fallback (default FixedDuration). Stores whose listings leave
+// the metadata out (OCI, S3) have each object stat'ed, one call per object.
+type MetadataDuration struct {
+	Key      string
+	Fallback DurationProvider
+}
+
+func (d MetadataDuration) Duration(ctx context.Context, side Side, rec dto.Record, count int) (float64, error) {
+	return listDuration(ctx, d, side, rec, count)
+}
+
+func (d MetadataDuration) newVisitor() durationVisitor {
+	return &metadataVisitor{key: d.Key, fallback: newDurationVisitor(fallback(d.Fallback))}
+}
+
+type metadataVisitor struct {
+	key      string
+	fallback durationVisitor
+	total    float64
+	found    bool
+	unlisted []string // objects listed without their metadata, stat'ed by duration
+}
+
+func (v *metadataVisitor) visit(rel string, attrs *objectstore.ObjectAttrs) error {
+	if err := v.fallback.visit(rel, attrs); err != nil {
+		return err
+	}
+	if attrs.Metadata == nil {
+		v.unlisted = append(v.unlisted, attrs.Name)
+		return nil
+	}
+	return v.add(attrs)
+}
+
+func (v *metadataVisitor) add(attrs *objectstore.ObjectAttrs) error {
+	value, ok := attrs.Metadata[v.key]
+	if !ok {
+		return nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("object '%s': invalid %s '%s'", attrs.Name, v.key, value)
+	}
+	v.total, v.found = v.total+f, true
+	return nil
+}
+
+func (v *metadataVisitor) duration(ctx context.Context, side Side, rec dto.Record, count int) (float64, error) {
+	for _, name := range v.unlisted {
+		attrs, err := side.Store.Stat(ctx, name)
+		if err != nil {
+			return 0, err
+		}
+		if err := v.add(attrs); err != nil {
+			return 0, err
+		}
+	}
+	v.unlisted = nil
+	if v.found {
+		return v.total, nil
+	}
+	return v.fallback.duration(ctx, side, rec, count)
+}
+
+// TimestampDuration takes the time between the first and the last upload, plus one
+// Segment (default SegmentDuration) for the length of the last one.
+type TimestampDuration struct {
+	Segment time.Duration
+}
+
+func (d TimestampDuration) Duration(ctx context.Context, side Side, rec dto.Record, count int) (float64, error) {
+	return listDuration(ctx, d, side, rec, count)
+}
+
+func (d TimestampDuration) newVisitor() durationVisitor {
+	return &timestampVisitor{segment: d.Segment}
+}
+
+type timestampVisitor struct {
+	segment     time.Duration
+	first, last time.Time
+}
+
+func (v *timestampVisitor) visit(rel string, attrs *objectstore.ObjectAttrs) error {
+	if v.first.IsZero() || attrs.Updated.Before(v.first) {
+		v.first = attrs.Updated
+	}
+	if attrs.Updated.After(v.last) {
+		v.last = attrs.Updated
+	}
+	return nil
+}
+
+func (v *timestampVisitor) duration(ctx context.Context, side Side, rec dto.Record, count int) (float64, error) {
+	if v.first.IsZero() {
+		return 0, nil
+	}
+	segment := v.segment
+	if segment <= 0 {
+		segment = SegmentDuration
+	}
+	return (v.last.Sub(v.first) + segment).Seconds(), nil
+}
+
+func fallback(p DurationProvider) DurationProvider {
+	if p == nil {
+		return FixedDuration{}
+	}
+	return p
+}
@@ -0,0 +1,102 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+func TestDurationProviders(t *testing.T) {
+	start := time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+	store := objectstore.NewMemoryStore("prod")
+	putFiles(store, rootPrefix, "plain", 4)
+	putFiles(store, rootPrefix, "hls", 4)
+	store.Put(rootPrefix+"hls/index.m3u8", []byte(mediaPlaylist(4, 9.5)))
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("%smeta/segment_%05d.ts", rootPrefix, i)
+		store.PutWithMetadata(name, []byte("x"), map[string]string{"duration": "10.5"})
+		store.SetUpdated(name, start.Add(time.Duration(i)*time.Minute))
+	}
+	store.PutWithMetadata(rootPrefix+"bad/segment_00000.ts", []byte("x"), map[string]string{"duration": "ten"})
+	side := Side{Store: store, RootPrefix: rootPrefix}
+
+	tests := []struct {
+		name     string
+		provider DurationProvider
+		id       string
+		count    int
+		want     float64
+		wantErr  string
+	}{
+		{name: "fixed default", provider: FixedDuration{}, id: "plain", count: 4, want: 60},
+		{name: "fixed segment", provider: FixedDuration{Segment: 6 * time.Second}, id: "plain", count: 4, want: 24},
+		{name: "manifest", provider: ManifestDuration{}, id: "hls", count: 5, want: 38},
+		{name: "manifest fallback", provider: ManifestDuration{Fallback: FixedDuration{Segment: 2 * time.Second}}, id: "plain", count: 4, want: 8},
+		{name: "metadata", provider: MetadataDuration{Key: "duration"}, id: "meta", count: 3, want: 31.5},
+		{name: "metadata fallback", provider: MetadataDuration{Key: "duration"}, id: "plain", count: 4, want: 60},
+		{name: "metadata invalid", provider: MetadataDuration{Key: "duration"}, id: "bad", count: 1, wantErr: "invalid duration 'ten'"},
+		{name: "timestamps", provider: TimestampDuration{}, id: "meta", count: 3, want: 135},
+		{name: "timestamps empty", provider: TimestampDuration{}, id: "missing", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.provider.Duration(context.Background(), side, dto.Record{ID: tt.id}, tt.count)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Duration() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Duration() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Duration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// unlistedMetadataStore lists like OCI and S3: without the custom metadata, which only Stat returns.
+type unlistedMetadataStore struct {
+	*objectstore.MemoryStore
+}
+
+func (s unlistedMetadataStore) List(ctx context.Context, prefix string) objectstore.ObjectIterator {
+	return unlistedMetadataIterator{s.MemoryStore.List(ctx, prefix)}
+}
+
+type unlistedMetadataIterator struct {
+	objectstore.ObjectIterator
+}
+
+func (i unlistedMetadataIterator) Next() (*objectstore.ObjectAttrs, error) {
+	attrs, err := i.ObjectIterator.Next()
+	if attrs != nil {
+		attrs.Metadata = nil
+	}
+	return attrs, err
+}
+
+func TestMetadataDurationUnlisted(t *testing.T) {
+	store := objectstore.NewMemoryStore("prod")
+	for i := 0; i < 3; i++ {
+		store.PutWithMetadata(fmt.Sprintf("%smeta/segment_%05d.ts", rootPrefix, i), []byte("x"), map[string]string{"duration": "10.5"})
+	}
+	store.Put(rootPrefix+"meta/index.m3u8", []byte("#EXTM3U\n"))
+	side := Side{Store: unlistedMetadataStore{store}, RootPrefix: rootPrefix}
+
+	got, err := MetadataDuration{Key: "duration"}.Duration(context.Background(), side, dto.Record{ID: "meta"}, 4)
+	if err != nil {
+		t.Fatalf("Duration() error = %v", err)
+	}
+	if got != 31.5 {
+		t.Errorf("Duration() = %v, want 31.5 from the stat'ed objects, not the fallback", got)
+	}
+}
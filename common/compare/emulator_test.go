@@ -0,0 +1,66 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"common/objectstore"
+)
+
+// TestBucketBasedComparisonEmulator runs the whole flow against fake-gcs-server, e.g.
+//
+//	docker run -p 4443:4443 fsouza/fake-gcs-server -scheme http
+//	STORAGE_EMULATOR_HOST=localhost:4443 go test ./compare -run Emulator
+func TestBucketBasedComparisonEmulator(t *testing.T) {
+	host := os.Getenv("STORAGE_EMULATOR_HOST")
+	if host == "" {
+		t.Skip("STORAGE_EMULATOR_HOST not set")
+	}
+	ctx := context.Background()
+
+	client, err := objectstore.NewGCSClient(ctx, objectstore.Config{Endpoint: host})
+	if err != nil {
+		t.Fatalf("NewGCSClient() error = %v", err)
+	}
+	defer client.Close()
+
+	suffix := time.Now().UnixNano()
+	bucket, bucket2 := fmt.Sprintf("prod-%d", suffix), fmt.Sprintf("temp-%d", suffix)
+	files := map[string][2]int{"id-1": {5, 5}, "id-2": {70, 10}, "id-3": {2, 9}}
+
+	for _, b := range []string{bucket, bucket2} {
+		if err := client.Bucket(b).Create(ctx, "test-project", nil); err != nil {
+			t.Fatalf("Create(%s) error = %v", b, err)
+		}
+	}
+	store, store2 := objectstore.NewGCSStore(client, bucket), objectstore.NewGCSStore(client, bucket2)
+	for id, n := range files {
+		for i := 0; i < n[0]; i++ {
+			if err := store.Write(ctx, fmt.Sprintf("%s%s/%d.ts", rootPrefix, id, i), strings.NewReader("x")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := 0; i < n[1]; i++ {
+			if err := store2.Write(ctx, fmt.Sprintf("%s%s/%d.ts", rootPrefix2, id, i), strings.NewReader("x")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	side := Side{Store: store, RootPrefix: rootPrefix}
+	side2 := Side{Store: store2, RootPrefix: rootPrefix2}
+	BucketBasedComparison(ctx, side, side2, Options{}, log.New(&out, "", 0))
+
+	for _, want := range []string{"id-2,1050,150", "Total IDs in temp bucket: 3", `MoreThan50: ("id-2")`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q, got:\n%s", want, out.String())
+		}
+	}
+}
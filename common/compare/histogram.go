@@ -0,0 +1,160 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"common/dto"
+)
+
+// Bounds of the original analysis: 1-4, 5-10, 11-20, ..., 451-500 and more than 500
+// more files in prod, and 1-10, ..., 51-100 and more than 100 less files in prod.
+var (
+	DefaultBoundaries    = []int{4, 10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 150, 200, 250, 300, 350, 400, 450, 500}
+	DefaultRevBoundaries = []int{10, 20, 30, 40, 50, 100}
+)
+
+// binEqual holds the IDs with the same no. of files in both buckets.
+const binEqual = "DiffEqual"
+
+// Histogram classifies the diff in no. of files per ID into bins, in both
+// directions: more files in bucket1 (prod) or more files in bucket2 (temp).
+// Bin names start with Diff, so they cannot be mistaken for the flag rules
+// listed in the same summary (e.g. the default rule MoreThan50).
+type Histogram struct {
+	Boundaries    []int // ascending upper bounds of the prod-more bins, e.g. 4, 10 gives Diff1To4, Diff5To10 and DiffMoreThan10; nil uses DefaultBoundaries
+	RevBoundaries []int // same for the temp-more bins, named with a Rev suffix (Diff1To10Rev); nil uses DefaultRevBoundaries
+	Percent       bool  // bin by the diff in percent of the smaller count (Diff0To10Pct, ...) instead of the no. of files
+}
+
+func (h Histogram) boundaries(rev bool) []int {
+	if rev {
+		if h.RevBoundaries == nil {
+			return DefaultRevBoundaries
+		}
+		return h.RevBoundaries
+	}
+	if h.Boundaries == nil {
+		return DefaultBoundaries
+	}
+	return h.Boundaries
+}
+
+// Validate checks that the boundaries are positive and ascending.
+func (h Histogram) Validate() error {
+	var errs []error
+	for _, rev := range []bool{false, true} {
+		bounds := h.boundaries(rev)
+		for i, b := range bounds {
+			if b <= 0 || (i > 0 && b <= bounds[i-1]) {
+				errs = append(errs, fmt.Errorf("boundaries %v: must be positive and ascending", bounds))
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Classify returns the name of the bin for an ID with count files in bucket1 and count2 in bucket2.
+func (h Histogram) Classify(count, count2 int) string {
+	if count == count2 {
+		return binEqual
+	}
+	rev := count < count2
+	diff, base := count-count2, count2
+	if rev {
+		diff, base = count2-count, count
+	}
+
+	value := float64(diff)
+	if h.Percent {
+		value = math.Inf(1) // from nothing to something
+		if base > 0 {
+			value = float64(diff) * 100 / float64(base)
+		}
+	}
+	bounds := h.boundaries(rev)
+	i := sort.Search(len(bounds), func(i int) bool { return value <= float64(bounds[i]) })
+	name, _ := h.bin(bounds, i, rev)
+	return name
+}
+
+// Bins returns the names of all bins and their descriptions for the summary,
+// from the most temp-more to the most prod-more bin.
+func (h Histogram) Bins() (names, labels []string) {
+	rev := h.boundaries(true)
+	for i := 0; i <= len(rev); i++ {
+		name, label := h.bin(rev, i, true)
+		names, labels = append(names, name), append(labels, label)
+	}
+	names, labels = append(names, binEqual), append(labels, "same files in prod bucket and temp bucket")
+	bounds := h.boundaries(false)
+	for i := 0; i <= len(bounds); i++ {
+		name, label := h.bin(bounds, i, false)
+		names, labels = append(names, name), append(labels, label)
+	}
+	return names, labels
+}
+
+// bin names the i-th bin of bounds; i == len(bounds) is the open-ended top bin.
+func (h Histogram) bin(bounds []int, i int, rev bool) (string, string) {
+	var name, label string
+	unit := ""
+	if h.Percent {
+		unit = "%"
+	}
+	switch {
+	case i == len(bounds):
+		top := 0
+		if i > 0 {
+			top = bounds[i-1]
+		}
+		name, label = fmt.Sprintf("DiffMoreThan%d", top), fmt.Sprintf("more than %d%s", top, unit)
+	case h.Percent:
+		lo := 0
+		if i > 0 {
+			lo = bounds[i-1]
+		}
+		name, label = fmt.Sprintf("Diff%dTo%d", lo, bounds[i]), fmt.Sprintf("%d-%d%%", lo, bounds[i])
+	default:
+		lo := 1
+		if i > 0 {
+			lo = bounds[i-1] + 1
+		}
+		name, label = fmt.Sprintf("Diff%dTo%d", lo, bounds[i]), fmt.Sprintf("%d-%d", lo, bounds[i])
+	}
+	if h.Percent {
+		name += "Pct"
+	}
+	if rev {
+		return name + "Rev", label + " less files in prod bucket than temp bucket"
+	}
+	return name, label + " more files in prod bucket than temp bucket"
+}
+
+// printHistogram logs the no. of IDs in each bin, then the IDs in every bin but Equal.
+func printHistogram(logger *log.Logger, h Histogram, cnt dto.Counts) {
+	names, labels := h.Bins()
+	for i, name := range names {
+		logger.Printf("Total IDs with %s: %d\n", labels[i], len(cnt[name]))
+	}
+	for _, name := range names {
+		if name != binEqual && len(cnt[name]) > 0 {
+			logger.Printf("%s: %s\n", name, quoteIDs(cnt[name]))
+		}
+	}
+}
+
+// quoteIDs formats ids as ("a", "b").
+func quoteIDs(ids []string) string {
+	quoted := make([]string, len(ids))
+	for i, id := range ids {
+		quoted[i] = `"` + id + `"`
+	}
+	sort.Strings(quoted)
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
@@ -0,0 +1,80 @@
+package compare
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"testing"
+
+	"common/dto"
+)
+
+func TestHistogramClassify(t *testing.T) {
+	tests := []struct {
+		name          string
+		hist          Histogram
+		count, count2 int
+		want          string
+	}{
+		{name: "equal", count: 7, count2: 7, want: "DiffEqual"},
+		{name: "first bin", count: 11, count2: 10, want: "Diff1To4"},
+		{name: "upper bound inclusive", count: 14, count2: 10, want: "Diff1To4"},
+		{name: "next bin", count: 15, count2: 10, want: "Diff5To10"},
+		{name: "top bin", count: 600, count2: 10, want: "DiffMoreThan500"},
+		{name: "reverse", count: 10, count2: 65, want: "Diff51To100Rev"},
+		{name: "reverse top bin", count: 0, count2: 101, want: "DiffMoreThan100Rev"},
+		{name: "custom bins", hist: Histogram{Boundaries: []int{2}, RevBoundaries: []int{5, 50}}, count: 3, count2: 30, want: "Diff6To50Rev"},
+		{name: "percent", hist: Histogram{Percent: true, Boundaries: []int{10, 50}}, count: 120, count2: 100, want: "Diff10To50Pct"},
+		{name: "percent reverse", hist: Histogram{Percent: true, RevBoundaries: []int{10, 50}}, count: 100, count2: 105, want: "Diff0To10PctRev"},
+		{name: "percent from nothing", hist: Histogram{Percent: true, Boundaries: []int{10, 50}}, count: 3, count2: 0, want: "DiffMoreThan50Pct"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hist.Classify(tt.count, tt.count2); got != tt.want {
+				t.Errorf("Classify(%d, %d) = %q, want %q", tt.count, tt.count2, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogramBins(t *testing.T) {
+	names, labels := Histogram{Boundaries: []int{4, 10}, RevBoundaries: []int{10}}.Bins()
+
+	wantNames := []string{"Diff1To10Rev", "DiffMoreThan10Rev", "DiffEqual", "Diff1To4", "Diff5To10", "DiffMoreThan10"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("names = %v, want %v", names, wantNames)
+	}
+	if want := "more than 10 less files in prod bucket than temp bucket"; labels[1] != want {
+		t.Errorf("labels[1] = %q, want %q", labels[1], want)
+	}
+}
+
+func TestHistogramValidate(t *testing.T) {
+	if err := (Histogram{}).Validate(); err != nil {
+		t.Errorf("Validate() default error = %v", err)
+	}
+	if err := (Histogram{Boundaries: []int{10, 5}}).Validate(); err == nil {
+		t.Error("Validate() descending boundaries error = nil")
+	}
+	if err := (Histogram{RevBoundaries: []int{0, 5}}).Validate(); err == nil {
+		t.Error("Validate() zero boundary error = nil")
+	}
+}
+
+func TestPrintHistogram(t *testing.T) {
+	var out bytes.Buffer
+	hist := Histogram{Boundaries: []int{4}, RevBoundaries: []int{4}}
+	printHistogram(log.New(&out, "", 0), hist, dto.Counts{"DiffEqual": {"a"}, "Diff1To4": {"c", "b"}})
+
+	want := `Total IDs with 1-4 less files in prod bucket than temp bucket: 0
+Total IDs with more than 4 less files in prod bucket than temp bucket: 0
+Total IDs with same files in prod bucket and temp bucket: 1
+Total IDs with 1-4 more files in prod bucket than temp bucket: 2
+Total IDs with more than 4 more files in prod bucket than temp bucket: 0
+Diff1To4: ("b", "c")
+`
+	if got := out.String(); got != want {
+		t.Errorf("output:\n%s\nwant:\n%s", got, want)
+	}
+}
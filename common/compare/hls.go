@@ -0,0 +1,71 @@
+package compare
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// playlistRef is an object referenced by a playlist.
+type playlistRef struct {
+	uri      string  // as written, relative to the playlist or an absolute URL
+	duration float64 // in sec, 0 for playlists and init segments
+}
+
+// rendition is the list of objects making up one rendition of a recording.
+type rendition []playlistRef
+
+func (r rendition) duration() float64 {
+	var d float64
+	for _, ref := range r {
+		d += ref.duration
+	}
+	return d
+}
+
+// hlsURIAttr matches the URI attribute of tags such as #EXT-X-MAP or #EXT-X-MEDIA.
+var hlsURIAttr = regexp.MustCompile(`URI="([^"]*)"`)
+
+// parseM3U8 parses an HLS playlist. A media playlist gives its segments with their
+// #EXTINF durations, a master playlist the variant playlists it references.
+func parseM3U8(r io.Reader) ([]rendition, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "#EXTM3U" {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("missing #EXTM3U header")
+	}
+
+	var refs rendition
+	var duration float64 // of the next segment, from its #EXTINF
+	for lineNo := 2; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			d, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid #EXTINF duration '%s'", lineNo, value)
+			}
+			duration = d
+		case strings.HasPrefix(line, "#EXT-X-MAP:"), strings.HasPrefix(line, "#EXT-X-MEDIA:"), strings.HasPrefix(line, "#EXT-X-I-FRAME-STREAM-INF:"):
+			// Init segments and alternative renditions
+			if match := hlsURIAttr.FindStringSubmatch(line); match != nil {
+				refs = append(refs, playlistRef{uri: match[1]})
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			refs = append(refs, playlistRef{uri: line, duration: duration})
+			duration = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return []rendition{refs}, nil
+}
@@ -0,0 +1,79 @@
+package compare
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"common/dto"
+)
+
+// UUIDPattern matches IDs such as fc8a9074-87d7-4c08-a0cb-ed4c00e0e91d.
+var UUIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// GetUniqueIDs lists the IDs (folders) IDDepth levels below the side's root prefix.
+// Prefixes whose folder name does not match IDPattern are returned as skipped.
+// Each record carries the intermediate folders as the "parent" field (e.g. 2024/12/01)
+// and any other named groups of IDPattern as fields, for use in the other side's template.
+func GetUniqueIDs(ctx context.Context, side Side) ([]dto.Record, []string, error) {
+	prefixes, err := side.Store.ListPrefixes(ctx, side.RootPrefix, "/")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Descend through intermediate folders, e.g. <yyyy>/<mm>/<dd>/
+	for depth := 0; depth < side.IDDepth; depth++ {
+		var next []string
+		for _, prefix := range prefixes {
+			children, err := side.Store.ListPrefixes(ctx, prefix, "/")
+			if err != nil {
+				return nil, nil, err
+			}
+			next = append(next, children...)
+		}
+		prefixes = next
+	}
+
+	var records []dto.Record
+	var skipped []string
+	for _, prefix := range prefixes {
+		// Extract ID (e.g fc8a9074-87d7-4c08-a0cb-ed4c00e0e91d) from the prefix (e.g CompositePreProcessing/v2/fc8a9074-87d7-4c08-a0cb-ed4c00e0e91d/)
+		rel := strings.TrimSuffix(strings.TrimPrefix(prefix, side.RootPrefix), "/")
+		i := strings.LastIndex(rel, "/")
+		rec, ok := side.extractID(rel[i+1:])
+		if !ok {
+			skipped = append(skipped, prefix)
+			continue
+		}
+		if i >= 0 {
+			rec.Fields["parent"] = rel[:i]
+		}
+		records = append(records, rec)
+	}
+
+	return records, skipped, nil
+}
+
+// extractID applies IDPattern to an ID folder name.
+func (s Side) extractID(name string) (dto.Record, bool) {
+	rec := dto.Record{ID: name, Fields: make(map[string]string)}
+	if s.IDPattern == nil {
+		return rec, name != ""
+	}
+	match := s.IDPattern.FindStringSubmatch(name)
+	if match == nil {
+		return rec, false
+	}
+	rec.ID = match[0]
+	if i := s.IDPattern.SubexpIndex("id"); i > 0 {
+		rec.ID = match[i]
+	} else if len(match) > 1 {
+		rec.ID = match[1]
+	}
+	for i, group := range s.IDPattern.SubexpNames() {
+		if group != "" && group != "id" {
+			rec.Fields[group] = match[i]
+		}
+	}
+	return rec, rec.ID != ""
+}
@@ -0,0 +1,96 @@
+package compare
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"common/objectstore"
+)
+
+func TestGetUniqueIDs(t *testing.T) {
+	const (
+		uuid1 = "fc8a9074-87d7-4c08-a0cb-ed4c00e0e91d"
+		uuid2 = "003b8f49-1f87-4782-945b-497948c30bea"
+	)
+
+	tests := []struct {
+		name        string
+		objects     []string
+		side        Side
+		wantIDs     []string
+		wantSkipped []string
+		wantFields  []map[string]string // checked when set
+	}{
+		{
+			name:    "two levels deep root",
+			objects: []string{"CompositePreProcessing/v2/" + uuid1 + "/0.ts", "CompositePreProcessing/v2/" + uuid2 + "/0.ts", "CompositePreProcessing/v2/stray.txt"},
+			side:    Side{RootPrefix: "CompositePreProcessing/v2/"},
+			wantIDs: []string{uuid2, uuid1},
+		},
+		{
+			name:    "ocs layout",
+			objects: []string{"CompleteLivestreamRecording/" + uuid1 + "/compositeRecording/0.ts", "CompleteLivestreamRecording/" + uuid1 + "/raw/0.ts"},
+			side:    Side{RootPrefix: "CompleteLivestreamRecording/", Template: "CompleteLivestreamRecording/{id}/compositeRecording"},
+			wantIDs: []string{uuid1},
+		},
+		{
+			name:        "uuid validator reports non-matching prefixes",
+			objects:     []string{"root/" + uuid1 + "/0.ts", "root/tmp/0.ts", "root/_backup/0.ts"},
+			side:        Side{RootPrefix: "root/", IDPattern: UUIDPattern},
+			wantIDs:     []string{uuid1},
+			wantSkipped: []string{"root/_backup/", "root/tmp/"},
+		},
+		{
+			name:    "date partitioned",
+			objects: []string{"root/2024/12/01/" + uuid1 + "/0.ts", "root/2024/12/02/" + uuid2 + "/0.ts", "root/2025/01/01/junk/0.ts"},
+			side:    Side{RootPrefix: "root/", IDDepth: 3, IDPattern: UUIDPattern},
+			wantIDs: []string{uuid1, uuid2}, wantSkipped: []string{"root/2025/01/01/junk/"},
+			wantFields: []map[string]string{{"parent": "2024/12/01"}, {"parent": "2024/12/02"}},
+		},
+		{
+			name:    "named group extracts the id",
+			objects: []string{"root/rec-" + uuid1 + "/0.ts", "root/" + uuid2 + "/0.ts"},
+			side:    Side{RootPrefix: "root/", IDPattern: regexp.MustCompile(`^rec-(?P<id>.+)$`)},
+			wantIDs: []string{uuid1}, wantSkipped: []string{"root/" + uuid2 + "/"},
+		},
+		{
+			name:       "other named groups become fields",
+			objects:    []string{"root/20241201-" + uuid1 + "/0.ts"},
+			side:       Side{RootPrefix: "root/", IDPattern: regexp.MustCompile(`^(?P<date>\d{8})-(?P<id>.+)$`)},
+			wantIDs:    []string{uuid1},
+			wantFields: []map[string]string{{"date": "20241201"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := objectstore.NewMemoryStore("bucket")
+			for _, name := range tt.objects {
+				m.Put(name, nil)
+			}
+			tt.side.Store = m
+
+			records, skipped, err := GetUniqueIDs(context.Background(), tt.side)
+			if err != nil {
+				t.Fatalf("GetUniqueIDs() error = %v", err)
+			}
+			var ids []string
+			var fields []map[string]string
+			for _, rec := range records {
+				ids = append(ids, rec.ID)
+				fields = append(fields, rec.Fields)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+			if !reflect.DeepEqual(skipped, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", skipped, tt.wantSkipped)
+			}
+			if tt.wantFields != nil && !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
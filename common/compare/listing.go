@@ -0,0 +1,207 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+// objectVisitor is fed the objects of an ID on one side, in order of name, by the
+// listing the checks of a comparison share, see compareAcrossBuckets.
+type objectVisitor interface {
+	// visit is called with each object and its name relative to the ID's prefix.
+	visit(rel string, attrs *objectstore.ObjectAttrs) error
+}
+
+// visitors feeds each object to all of its visitors.
+type visitors []objectVisitor
+
+func (vs visitors) visit(rel string, attrs *objectstore.ObjectAttrs) error {
+	for _, v := range vs {
+		if err := v.visit(rel, attrs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listPrefix returns the prefix the objects of rec are listed under on side: its
+// Prefix as a folder, ending in "/". An ID that is a prefix of another ID (abc,
+// abc0) thus only gets its own objects, and the names relative to it sort like
+// the full names, which the merge of two listings relies on.
+func listPrefix(side Side, rec dto.Record) (string, error) {
+	prefix, err := side.Prefix(rec)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix, nil
+}
+
+// relName returns the name of an object relative to the prefix of its ID.
+func relName(prefix, name string) string {
+	return strings.TrimPrefix(name, prefix)
+}
+
+// visitSide lists the objects of rec on side and feeds them to v.
+func visitSide(ctx context.Context, side Side, rec dto.Record, v objectVisitor) error {
+	prefix, err := listPrefix(side, rec)
+	if err != nil {
+		return err
+	}
+	it := side.Store.List(ctx, prefix)
+	defer it.Stop()
+	for {
+		attrs, err := it.Next()
+		if err == objectstore.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := v.visit(relName(prefix, attrs.Name), attrs); err != nil {
+			return err
+		}
+	}
+}
+
+// listingError is a listing of one side that failed.
+type listingError struct {
+	bucket string
+	err    error
+}
+
+func (e *listingError) Error() string { return e.err.Error() }
+func (e *listingError) Unwrap() error { return e.err }
+
+// listingBuffer is the no. of objects a listing fetches ahead of the merge.
+const listingBuffer = 1000
+
+// listItem is an object of a listing, or the error that ended it.
+type listItem struct {
+	attrs *objectstore.ObjectAttrs
+	err   error
+}
+
+// sideListing pages in the objects of an ID on one side in the background, so
+// both sides are listed concurrently, and returns them checking they come sorted.
+type sideListing struct {
+	ctx    context.Context
+	ch     <-chan listItem
+	bucket string
+	prefix string
+	last   string
+}
+
+// listSide starts listing rec on side; the listing stops once ctx is done.
+func listSide(ctx context.Context, side Side, rec dto.Record) (*sideListing, error) {
+	prefix, err := listPrefix(side, rec)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan listItem, listingBuffer)
+	go func() {
+		defer close(ch)
+		it := side.Store.List(ctx, prefix)
+		defer it.Stop()
+		for {
+			attrs, err := it.Next()
+			if err == objectstore.Done {
+				return
+			}
+			select {
+			case ch <- listItem{attrs: attrs, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return &sideListing{ctx: ctx, ch: ch, bucket: side.Store.Name(), prefix: prefix}, nil
+}
+
+// next returns the next object and its name relative to the ID's prefix; attrs
+// is nil once the listing is exhausted.
+func (l *sideListing) next() (string, *objectstore.ObjectAttrs, error) {
+	var item listItem
+	var ok bool
+	select {
+	case item, ok = <-l.ch:
+	case <-l.ctx.Done():
+	}
+	if !ok {
+		// A listing cut short by ctx is not exhausted
+		if err := l.ctx.Err(); err != nil {
+			return "", nil, &listingError{bucket: l.bucket, err: err}
+		}
+		return "", nil, nil
+	}
+	if item.err != nil {
+		return "", nil, &listingError{bucket: l.bucket, err: item.err}
+	}
+	// The merge relies on the order, a store returning unsorted names would pair the wrong objects
+	if item.attrs.Name <= l.last {
+		return "", nil, &listingError{bucket: l.bucket, err: fmt.Errorf("bucket '%s': listing not sorted, '%s' after '%s'", l.bucket, item.attrs.Name, l.last)}
+	}
+	l.last = item.attrs.Name
+	return relName(l.prefix, item.attrs.Name), item.attrs, nil
+}
+
+// mergeListings lists rec once on each side, concurrently, and merges the
+// listings by name relative to the ID's prefix: fn is called with each name
+// and its object on each side, nil on the side it is missing from. Only the
+// objects fetched ahead of the merge are held in memory. Listing errors are
+// a *listingError naming the bucket.
+func mergeListings(ctx context.Context, rec dto.Record, side, side2 Side, fn func(rel string, attrs, attrs2 *objectstore.ObjectAttrs) error) error {
+	ctx, cancel := context.WithCancel(ctx) // stops the listings when the merge ends early
+	defer cancel()
+	l, err := listSide(ctx, side, rec)
+	if err != nil {
+		return err
+	}
+	l2, err := listSide(ctx, side2, rec)
+	if err != nil {
+		return err
+	}
+
+	rel, attrs, err := l.next()
+	if err != nil {
+		return err
+	}
+	rel2, attrs2, err := l2.next()
+	if err != nil {
+		return err
+	}
+	for attrs != nil || attrs2 != nil {
+		switch {
+		case attrs != nil && (attrs2 == nil || rel < rel2):
+			if err := fn(rel, attrs, nil); err != nil {
+				return err
+			}
+			rel, attrs, err = l.next()
+		case attrs2 != nil && (attrs == nil || rel2 < rel):
+			if err := fn(rel2, nil, attrs2); err != nil {
+				return err
+			}
+			rel2, attrs2, err = l2.next()
+		default:
+			if err := fn(rel, attrs, attrs2); err != nil {
+				return err
+			}
+			if rel, attrs, err = l.next(); err == nil {
+				rel2, attrs2, err = l2.next()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
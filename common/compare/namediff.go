@@ -0,0 +1,85 @@
+package compare
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"log"
+	"sync"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+// Where an object name was found, as written by DiffWriter.
+const (
+	OnlyInSource = "source" // only in bucket1
+	OnlyInTarget = "target" // only in bucket2
+	InBoth       = "both"
+)
+
+// DiffNames merges the listings of rec on both sides, which stores return sorted
+// by name, and calls emit with each name (relative to the ID's prefix) and where
+// it was found. Only the objects paged in ahead of the merge are held in memory.
+func DiffNames(ctx context.Context, rec dto.Record, side, side2 Side, emit func(name, where string) error) (dto.NameDiff, error) {
+	var diff dto.NameDiff
+	err := mergeListings(ctx, rec, side, side2, func(rel string, attrs, attrs2 *objectstore.ObjectAttrs) error {
+		return emit(rel, countName(&diff, attrs, attrs2))
+	})
+	return diff, err
+}
+
+// countName counts a name found with attrs in bucket1 and attrs2 in bucket2
+// (nil where missing) in diff, and returns where it was found.
+func countName(diff *dto.NameDiff, attrs, attrs2 *objectstore.ObjectAttrs) string {
+	switch {
+	case attrs2 == nil:
+		diff.OnlyInSource++
+		return OnlyInSource
+	case attrs == nil:
+		diff.OnlyInTarget++
+		return OnlyInTarget
+	}
+	diff.InBoth++
+	return InBoth
+}
+
+// DiffWriter writes the object names found by DiffNames as CSV rows "id,where,name",
+// where is one of OnlyInSource, OnlyInTarget or InBoth. It is safe for concurrent use.
+type DiffWriter struct {
+	mu   sync.Mutex
+	w    *csv.Writer
+	both bool
+}
+
+// NewDiffWriter returns a DiffWriter writing to w. Names found in both buckets
+// are only written when both is set, they are most of the rows when the buckets agree.
+func NewDiffWriter(w io.Writer, both bool) *DiffWriter {
+	d := &DiffWriter{w: csv.NewWriter(w), both: both}
+	d.w.Write([]string{"id", "where", "name"}) // errors are kept for Flush
+	return d
+}
+
+// Write writes one row.
+func (d *DiffWriter) Write(id, name, where string) error {
+	if where == InBoth && !d.both {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.w.Write([]string{id, where, name})
+}
+
+// Flush writes any buffered rows.
+func (d *DiffWriter) Flush() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.w.Flush()
+	return d.w.Error()
+}
+
+// logNames logs the name diff for res.
+func logNames(logger *log.Logger, res dto.Result, bucket, bucket2 string) {
+	n := res.Names
+	logger.Printf("diff '%s': %d only in bucket1 '%s', %d only in bucket2 '%s', %d in both\n", res.ID, n.OnlyInSource, bucket, n.OnlyInTarget, bucket2, n.InBoth)
+}
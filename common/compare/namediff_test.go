@@ -0,0 +1,151 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+// reversedStore lists objects in reverse order, unlike any real store.
+type reversedStore struct {
+	*objectstore.MemoryStore
+}
+
+func (s reversedStore) List(ctx context.Context, prefix string) objectstore.ObjectIterator {
+	it := s.MemoryStore.List(ctx, prefix)
+	var objects []*objectstore.ObjectAttrs
+	for {
+		attrs, err := it.Next()
+		if err != nil {
+			break
+		}
+		objects = append([]*objectstore.ObjectAttrs{attrs}, objects...)
+	}
+	return &sliceIterator{objects: objects}
+}
+
+type sliceIterator struct {
+	objects []*objectstore.ObjectAttrs
+}
+
+func (i *sliceIterator) Next() (*objectstore.ObjectAttrs, error) {
+	if len(i.objects) == 0 {
+		return nil, objectstore.Done
+	}
+	attrs := i.objects[0]
+	i.objects = i.objects[1:]
+	return attrs, nil
+}
+
+func (i *sliceIterator) Stop() {}
+
+func TestDiffNames(t *testing.T) {
+	tests := []struct {
+		name          string
+		files, files2 []string
+		pageSize      int
+		want          dto.NameDiff
+		wantRows      []string
+	}{
+		{
+			name:     "interleaved",
+			files:    []string{"0.ts", "1.ts", "3.ts"},
+			files2:   []string{"1.ts", "2.ts", "3.ts", "4.ts"},
+			want:     dto.NameDiff{OnlyInSource: 1, OnlyInTarget: 2, InBoth: 2},
+			wantRows: []string{"0.ts source", "1.ts both", "2.ts target", "3.ts both", "4.ts target"},
+		},
+		{
+			name:     "empty target",
+			files:    []string{"0.ts", "1.ts"},
+			want:     dto.NameDiff{OnlyInSource: 2},
+			wantRows: []string{"0.ts source", "1.ts source"},
+		},
+		{
+			name:     "nested names, paginated",
+			files:    []string{"a/0.ts", "a/1.ts", "b/0.ts"},
+			files2:   []string{"a/1.ts", "b/0.ts", "b/1.ts"},
+			pageSize: 1,
+			want:     dto.NameDiff{OnlyInSource: 1, OnlyInTarget: 1, InBoth: 2},
+			wantRows: []string{"a/0.ts source", "a/1.ts both", "b/0.ts both", "b/1.ts target"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prod := objectstore.NewMemoryStore("prod")
+			temp := objectstore.NewMemoryStore("temp")
+			prod.PageSize, temp.PageSize = tt.pageSize, tt.pageSize
+			for _, name := range tt.files {
+				prod.Put(rootPrefix+"id/"+name, []byte("x"))
+			}
+			for _, name := range tt.files2 {
+				temp.Put("2024/12/01/id/"+name, []byte("x"))
+			}
+			side := Side{Store: prod, RootPrefix: rootPrefix}
+			side2 := Side{Store: temp, Template: "2024/12/01/{id}/"}
+
+			var rows []string
+			got, err := DiffNames(context.Background(), dto.Record{ID: "id"}, side, side2, func(name, where string) error {
+				rows = append(rows, name+" "+where)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("DiffNames() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DiffNames() = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("rows = %q, want %q", rows, tt.wantRows)
+			}
+		})
+	}
+}
+
+func TestDiffNamesUnsorted(t *testing.T) {
+	prod := objectstore.NewMemoryStore("prod")
+	putFiles(prod, rootPrefix, "id", 3)
+	side := Side{Store: reversedStore{prod}, RootPrefix: rootPrefix}
+	side2 := Side{Store: objectstore.NewMemoryStore("temp"), RootPrefix: rootPrefix2}
+
+	_, err := DiffNames(context.Background(), dto.Record{ID: "id"}, side, side2, func(name, where string) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "listing not sorted") {
+		t.Errorf("DiffNames() error = %v, want listing not sorted", err)
+	}
+}
+
+func TestBucketBasedComparisonDiff(t *testing.T) {
+	files := map[string]int{"same": 2}
+	files2 := map[string]int{"same": 2, "short": 3}
+	for i := 0; i < 50; i++ {
+		files[fmt.Sprintf("id-%02d", i)], files2[fmt.Sprintf("id-%02d", i)] = 1, 1
+	}
+	side, side2, prod, _ := newSides(files, files2)
+	prod.Put(rootPrefix+"short/segment_00000.ts", []byte("x"))
+	prod.Put(rootPrefix+"short/segment_00007.ts", []byte("x"))
+
+	var out, diff bytes.Buffer
+	w := NewDiffWriter(&diff, false)
+	BucketBasedComparison(context.Background(), side, side2, Options{Workers: 4, Diff: w}, log.New(&out, "", 0))
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id,where,name\nshort,target,segment_00001.ts\nshort,target,segment_00002.ts\nshort,source,segment_00007.ts\n"
+	if diff.String() != want {
+		t.Errorf("diff = %q, want %q", diff.String(), want)
+	}
+	if want := "diff 'short': 1 only in bucket1 'prod', 2 only in bucket2 'temp', 1 in both"; !strings.Contains(out.String(), want) {
+		t.Errorf("output missing %q, got:\n%s", want, out.String())
+	}
+	if strings.Contains(out.String(), "diff 'same'") {
+		t.Errorf("ID without differences logged, got:\n%s", out.String())
+	}
+}
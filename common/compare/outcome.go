@@ -0,0 +1,93 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+)
+
+var (
+	// ErrTimeout is returned for an ID whose files were not counted within Options.Timeout.
+	ErrTimeout = errors.New("timeout while waiting for data")
+	// ErrSkipped is returned for an ID that was not compared, e.g. because it was
+	// skipped in the run resumed from (see Checkpoint).
+	ErrSkipped = errors.New("skipped")
+)
+
+// Outcome names how the comparison of an ID ended.
+const (
+	Compared = "compared"
+	Errored  = "errored"
+	TimedOut = "timed out"
+	Skipped  = "skipped"
+)
+
+// Failure is an ID that was not compared, and why.
+type Failure struct {
+	ID      string
+	Outcome string // Errored, TimedOut or Skipped
+	Cause   string
+}
+
+// Outcomes counts how the comparison of each ID ended.
+type Outcomes struct {
+	Compared, Errored, TimedOut, Skipped int
+	Failures                             []Failure
+}
+
+// add records the outcome of comparing id, which failed unless err is nil.
+func (o *Outcomes) add(id string, err error) {
+	outcome := outcomeOf(err)
+	switch outcome {
+	case Compared:
+		o.Compared++
+		return
+	case TimedOut:
+		o.TimedOut++
+	case Skipped:
+		o.Skipped++
+	default:
+		o.Errored++
+	}
+	o.Failures = append(o.Failures, Failure{ID: id, Outcome: outcome, Cause: err.Error()})
+}
+
+func outcomeOf(err error) string {
+	switch {
+	case err == nil:
+		return Compared
+	case errors.Is(err, ErrTimeout):
+		return TimedOut
+	case errors.Is(err, ErrSkipped), errors.Is(err, context.Canceled):
+		return Skipped
+	}
+	return Errored
+}
+
+// Total returns the no. of IDs with an outcome.
+func (o Outcomes) Total() int {
+	return o.Compared + o.Errored + o.TimedOut + o.Skipped
+}
+
+// ErrorRate returns the share of IDs that were not compared, 0 without IDs.
+func (o Outcomes) ErrorRate() float64 {
+	if o.Total() == 0 {
+		return 0
+	}
+	return float64(len(o.Failures)) / float64(o.Total())
+}
+
+// printOutcomes logs the no. of IDs per outcome and the cause of each failure.
+func printOutcomes(logger *log.Logger, o Outcomes) {
+	logger.Printf("Total IDs compared: %d\n", o.Compared)
+	logger.Printf("Total IDs errored: %d\n", o.Errored)
+	logger.Printf("Total IDs timed out: %d\n", o.TimedOut)
+	logger.Printf("Total IDs skipped: %d\n", o.Skipped)
+
+	failures := append([]Failure(nil), o.Failures...)
+	sort.Slice(failures, func(i, j int) bool { return failures[i].ID < failures[j].ID })
+	for _, f := range failures {
+		logger.Printf("Failed ID '%s': %s: %s\n", f.ID, f.Outcome, f.Cause)
+	}
+}
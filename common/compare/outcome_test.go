@@ -0,0 +1,34 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestOutcomes(t *testing.T) {
+	var o Outcomes
+	for id, err := range map[string]error{
+		"a": nil,
+		"b": nil,
+		"c": errors.New("boom"),
+		"d": fmt.Errorf("%w for ID 'd'", ErrTimeout),
+		"e": fmt.Errorf("%w: failed in an earlier run: boom", ErrSkipped),
+		"f": fmt.Errorf("failed to list objects: %w", context.Canceled),
+		"g": nil,
+		"h": nil,
+	} {
+		o.add(id, err)
+	}
+
+	if o.Compared != 4 || o.Errored != 1 || o.TimedOut != 1 || o.Skipped != 2 {
+		t.Errorf("outcomes = %d compared, %d errored, %d timed out, %d skipped, want 4, 1, 1, 2", o.Compared, o.Errored, o.TimedOut, o.Skipped)
+	}
+	if got := o.ErrorRate(); got != 0.5 {
+		t.Errorf("ErrorRate() = %v, want 0.5", got)
+	}
+	if got := (Outcomes{}).ErrorRate(); got != 0 {
+		t.Errorf("ErrorRate() without IDs = %v, want 0", got)
+	}
+}
@@ -0,0 +1,200 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+// Playlist findings listed in the summary, with a 2 suffix for bucket2, see classifyPlaylist.
+const (
+	noPlaylist     = "NoPlaylist"     // no playlist under the ID's prefix
+	brokenPlaylist = "BrokenPlaylist" // invalid playlists or references to missing segments
+	unreferenced   = "Unreferenced"   // segments no playlist references
+)
+
+// isPlaylist reports whether name is an HLS playlist, or with mpd a DASH manifest.
+func isPlaylist(name string, mpd bool) bool {
+	return strings.HasSuffix(name, ".m3u8") || mpd && strings.HasSuffix(name, ".mpd")
+}
+
+func isAbsoluteURL(uri string) bool {
+	return strings.Contains(uri, "://")
+}
+
+// ValidatePlaylists lists the objects of rec on side, parses the HLS playlists
+// (.m3u8) among them, and with mpd the DASH manifests (.mpd), and checks that every
+// object they reference exists and that every other object is referenced. The
+// durations of the segments are summed into the length of the recording.
+func ValidatePlaylists(ctx context.Context, side Side, rec dto.Record, mpd bool) (dto.Playlist, error) {
+	v := newPlaylistVisitor(mpd)
+	if err := visitSide(ctx, side, rec, v); err != nil {
+		return dto.Playlist{}, err
+	}
+	return v.validate(ctx, side.Store)
+}
+
+// playlistVisitor collects the names of the objects of an ID on one side and
+// its playlists, see ValidatePlaylists.
+type playlistVisitor struct {
+	mpd       bool
+	exists    map[string]bool // relative names of all objects
+	playlists []*objectstore.ObjectAttrs
+	rels      []string // relative names of the playlists
+}
+
+func newPlaylistVisitor(mpd bool) *playlistVisitor {
+	return &playlistVisitor{mpd: mpd, exists: make(map[string]bool)}
+}
+
+func (v *playlistVisitor) visit(rel string, attrs *objectstore.ObjectAttrs) error {
+	v.exists[rel] = true
+	if isPlaylist(attrs.Name, v.mpd) {
+		v.playlists = append(v.playlists, attrs)
+		v.rels = append(v.rels, rel)
+	}
+	return nil
+}
+
+// validate reads the playlists collected from store and matches their references
+// with the objects.
+func (v *playlistVisitor) validate(ctx context.Context, store objectstore.Store) (dto.Playlist, error) {
+	p := dto.Playlist{Playlists: len(v.playlists)}
+	referenced := make(map[string]bool)
+	for i, attrs := range v.playlists {
+		rel := v.rels[i]
+		renditions, parseErr, err := readPlaylist(ctx, store, attrs.Name)
+		if err != nil {
+			return p, err
+		}
+		if parseErr != nil {
+			p.Invalid = append(p.Invalid, fmt.Sprintf("%s: %v", rel, parseErr))
+			continue
+		}
+		dir := path.Dir(rel)
+		for _, r := range renditions {
+			p.Duration = math.Max(p.Duration, r.duration())
+			for _, ref := range r {
+				if isAbsoluteURL(ref.uri) {
+					p.External++
+					continue
+				}
+				uri, _, _ := strings.Cut(ref.uri, "?")
+				referenced[path.Join(dir, uri)] = true
+			}
+		}
+	}
+
+	p.Referenced = len(referenced)
+	for name := range referenced {
+		if !v.exists[name] {
+			p.Missing = append(p.Missing, name)
+		}
+	}
+	for name := range v.exists {
+		if !referenced[name] && !isPlaylist(name, v.mpd) {
+			p.Unreferenced = append(p.Unreferenced, name)
+		}
+	}
+	sort.Strings(p.Missing)
+	sort.Strings(p.Unreferenced)
+	return p, nil
+}
+
+// readPlaylist reads and parses a playlist. parseErr is set for a playlist that
+// could be read but not parsed, a finding rather than a failure.
+func readPlaylist(ctx context.Context, store objectstore.Store, name string) (renditions []rendition, parseErr, err error) {
+	r, err := store.Read(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	if strings.HasSuffix(name, ".mpd") {
+		renditions, parseErr = parseMPD(r)
+	} else {
+		renditions, parseErr = parseM3U8(r)
+	}
+	return renditions, parseErr, nil
+}
+
+// playlistOK reports whether a bucket has a playlist matching its objects.
+func playlistOK(p dto.Playlist) bool {
+	return p.Playlists > 0 && len(p.Invalid) == 0 && len(p.Missing) == 0 && len(p.Unreferenced) == 0
+}
+
+// classifyPlaylist returns the playlist findings for res, with a 2 suffix for bucket2.
+func classifyPlaylist(res dto.Result) []string {
+	var found []string
+	for _, s := range []struct {
+		p      dto.Playlist
+		suffix string
+	}{{res.Playlist, ""}, {res.Playlist2, bucket2Suffix}} {
+		if s.p.Playlists == 0 {
+			found = append(found, noPlaylist+s.suffix)
+			continue
+		}
+		if len(s.p.Invalid) > 0 || len(s.p.Missing) > 0 {
+			found = append(found, brokenPlaylist+s.suffix)
+		}
+		if len(s.p.Unreferenced) > 0 {
+			found = append(found, unreferenced+s.suffix)
+		}
+	}
+	return found
+}
+
+// maxLoggedNames caps the names listed per problem in the per-ID line.
+const maxLoggedNames = 10
+
+// logPlaylist logs the playlist problems of res in each bucket that has any.
+func logPlaylist(logger *log.Logger, res dto.Result, bucket, bucket2 string) {
+	for _, s := range []struct {
+		p      dto.Playlist
+		label  string
+		bucket string
+	}{{res.Playlist, "bucket1", bucket}, {res.Playlist2, "bucket2", bucket2}} {
+		if playlistOK(s.p) {
+			continue
+		}
+		if s.p.Playlists == 0 {
+			logger.Printf("playlist '%s': %s '%s': no playlist\n", res.ID, s.label, s.bucket)
+			continue
+		}
+		logger.Printf("playlist '%s': %s '%s': %d playlist(s), %d invalid (%s), %d referenced, %d missing (%s), %d unreferenced (%s), %.1f sec\n",
+			res.ID, s.label, s.bucket, s.p.Playlists,
+			len(s.p.Invalid), formatNames(s.p.Invalid), s.p.Referenced,
+			len(s.p.Missing), formatNames(s.p.Missing),
+			len(s.p.Unreferenced), formatNames(s.p.Unreferenced), s.p.Duration)
+	}
+}
+
+// formatNames joins names, eliding all but the first maxLoggedNames.
+func formatNames(names []string) string {
+	if len(names) > maxLoggedNames {
+		return strings.Join(names[:maxLoggedNames], ", ") + fmt.Sprintf(", ... %d more", len(names)-maxLoggedNames)
+	}
+	return strings.Join(names, ", ")
+}
+
+// printPlaylist logs the no. of IDs with each playlist finding, then the IDs themselves.
+func printPlaylist(logger *log.Logger, findings dto.Counts) {
+	logger.Printf("Total IDs without playlist in prod bucket: %d\n", len(findings[noPlaylist]))
+	logger.Printf("Total IDs without playlist in temp bucket: %d\n", len(findings[noPlaylist+bucket2Suffix]))
+	logger.Printf("Total IDs with broken playlists in prod bucket: %d\n", len(findings[brokenPlaylist]))
+	logger.Printf("Total IDs with broken playlists in temp bucket: %d\n", len(findings[brokenPlaylist+bucket2Suffix]))
+	logger.Printf("Total IDs with unreferenced segments in prod bucket: %d\n", len(findings[unreferenced]))
+	logger.Printf("Total IDs with unreferenced segments in temp bucket: %d\n", len(findings[unreferenced+bucket2Suffix]))
+	for _, name := range []string{noPlaylist, noPlaylist + bucket2Suffix, brokenPlaylist, brokenPlaylist + bucket2Suffix, unreferenced, unreferenced + bucket2Suffix} {
+		if len(findings[name]) > 0 {
+			logger.Printf("%s: %s\n", name, quoteIDs(findings[name]))
+		}
+	}
+}
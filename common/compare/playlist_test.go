@@ -0,0 +1,243 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+// mediaPlaylist returns an HLS playlist of n segments of d sec each.
+func mediaPlaylist(n int, d float64) string {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-TARGETDURATION:15\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "#EXTINF:%.3f,\nsegment_%05d.ts\n", d, i)
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String()
+}
+
+func TestParseM3U8(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist string
+		want     rendition
+		wantErr  bool
+	}{
+		{
+			name:     "media",
+			playlist: "#EXTM3U\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:15.0,\nseg0.m4s\n\n#EXTINF:9.5,title\nseg1.m4s?token=x\n#EXT-X-ENDLIST\n",
+			want:     rendition{{uri: "init.mp4"}, {uri: "seg0.m4s", duration: 15}, {uri: "seg1.m4s?token=x", duration: 9.5}},
+		},
+		{
+			name:     "master",
+			playlist: "#EXTM3U\n#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"a\",URI=\"audio/index.m3u8\"\n#EXT-X-STREAM-INF:BANDWIDTH=1280000\n720p/index.m3u8\n",
+			want:     rendition{{uri: "audio/index.m3u8"}, {uri: "720p/index.m3u8"}},
+		},
+		{name: "no header", playlist: "#EXTINF:15,\nseg0.ts\n", wantErr: true},
+		{name: "bad duration", playlist: "#EXTM3U\n#EXTINF:abc,\nseg0.ts\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseM3U8(strings.NewReader(tt.playlist))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseM3U8() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, []rendition{tt.want}) {
+				t.Errorf("parseM3U8() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMPD(t *testing.T) {
+	tests := []struct {
+		name         string
+		manifest     string
+		wantURIs     [][]string
+		wantDuration []float64
+		wantErr      bool
+	}{
+		{
+			name: "segment timeline",
+			manifest: `<MPD><Period><AdaptationSet>
+				<SegmentTemplate media="$RepresentationID$/chunk-$Number%05d$.m4s" initialization="$RepresentationID$/init.mp4" startNumber="0" timescale="1000">
+					<SegmentTimeline><S t="0" d="15000" r="1"/><S d="4000"/></SegmentTimeline>
+				</SegmentTemplate>
+				<Representation id="720p"/>
+			</AdaptationSet></Period></MPD>`,
+			wantURIs:     [][]string{{"720p/init.mp4", "720p/chunk-00000.m4s", "720p/chunk-00001.m4s", "720p/chunk-00002.m4s"}},
+			wantDuration: []float64{34},
+		},
+		{
+			name: "fixed duration",
+			manifest: `<MPD mediaPresentationDuration="PT40S"><Period><AdaptationSet>
+				<Representation id="a"><BaseURL>audio/</BaseURL><SegmentTemplate media="seg-$Time$.m4s" timescale="10" duration="150"/></Representation>
+			</AdaptationSet></Period></MPD>`,
+			wantURIs:     [][]string{{"audio/seg-0.m4s", "audio/seg-150.m4s", "audio/seg-300.m4s"}},
+			wantDuration: []float64{40},
+		},
+		{
+			name: "segment list and absolute base",
+			manifest: `<MPD><Period><AdaptationSet><Representation id="v">
+				<SegmentList timescale="1" duration="15"><Initialization sourceURL="init.mp4"/><SegmentURL media="s1.m4s"/><SegmentURL media="https://cdn/s2.m4s"/></SegmentList>
+			</Representation></AdaptationSet></Period></MPD>`,
+			wantURIs:     [][]string{{"init.mp4", "s1.m4s", "https://cdn/s2.m4s"}},
+			wantDuration: []float64{30},
+		},
+		{name: "not xml", manifest: "#EXTM3U", wantErr: true},
+		{name: "repeat past the cap", manifest: `<MPD><Period><AdaptationSet><Representation id="v"><SegmentTemplate media="$Number$.m4s"><SegmentTimeline><S d="1" r="9223372036854775807"/></SegmentTimeline></SegmentTemplate></Representation></AdaptationSet></Period></MPD>`, wantErr: true},
+		{name: "duration past the cap", manifest: `<MPD mediaPresentationDuration="P10000D"><Period><AdaptationSet><Representation id="v"><SegmentTemplate media="$Number$.m4s" duration="1"/></Representation></AdaptationSet></Period></MPD>`, wantErr: true},
+		{name: "template without duration", manifest: `<MPD><Period><AdaptationSet><Representation id="v"><SegmentTemplate media="$Number$.m4s"/></Representation></AdaptationSet></Period></MPD>`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMPD(strings.NewReader(tt.manifest))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMPD() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var uris [][]string
+			var durations []float64
+			for _, r := range got {
+				var names []string
+				for _, ref := range r {
+					names = append(names, ref.uri)
+				}
+				uris = append(uris, names)
+				durations = append(durations, r.duration())
+			}
+			if !reflect.DeepEqual(uris, tt.wantURIs) || !reflect.DeepEqual(durations, tt.wantDuration) {
+				t.Errorf("parseMPD() = %q %v, want %q %v", uris, durations, tt.wantURIs, tt.wantDuration)
+			}
+		})
+	}
+}
+
+func TestParseMPDCap(t *testing.T) {
+	defer func(n int) { maxMPDSegments = n }(maxMPDSegments)
+	maxMPDSegments = 5
+
+	manifest := `<MPD mediaPresentationDuration="PT3S"><Period><AdaptationSet><SegmentTemplate media="$RepresentationID$/$Number$.m4s" duration="1"/>
+		<Representation id="a"/><Representation id="b"/>
+	</AdaptationSet></Period></MPD>`
+	if _, err := parseMPD(strings.NewReader(manifest)); err == nil || !strings.Contains(err.Error(), "more than 5 segments") {
+		t.Errorf("parseMPD() error = %v, want the cap across representations", err)
+	}
+}
+
+func TestValidatePlaylists(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects map[string]string
+		mpd     bool
+		want    dto.Playlist
+	}{
+		{
+			name:    "complete",
+			objects: map[string]string{"index.m3u8": mediaPlaylist(2, 15), "segment_00000.ts": "x", "segment_00001.ts": "x"},
+			want:    dto.Playlist{Playlists: 1, Referenced: 2, Duration: 30},
+		},
+		{
+			name: "missing and unreferenced",
+			objects: map[string]string{
+				"index.m3u8":            "#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\n720p/index.m3u8\n",
+				"720p/index.m3u8":       mediaPlaylist(3, 14.5),
+				"720p/segment_00000.ts": "x", "720p/segment_00002.ts": "x", "720p/segment_00003.ts": "x",
+			},
+			want: dto.Playlist{Playlists: 2, Referenced: 4, Missing: []string{"720p/segment_00001.ts"}, Unreferenced: []string{"720p/segment_00003.ts"}, Duration: 43.5},
+		},
+		{
+			name:    "invalid playlist",
+			objects: map[string]string{"index.m3u8": "garbage", "segment_00000.ts": "x"},
+			want:    dto.Playlist{Playlists: 1, Invalid: []string{"index.m3u8: missing #EXTM3U header"}, Unreferenced: []string{"segment_00000.ts"}},
+		},
+		{
+			name:    "dash ignored without mpd",
+			objects: map[string]string{"manifest.mpd": `<MPD><Period><AdaptationSet><Representation id="v"><SegmentList duration="15"><SegmentURL media="a.m4s"/></SegmentList></Representation></AdaptationSet></Period></MPD>`, "a.m4s": "x"},
+			want:    dto.Playlist{Unreferenced: []string{"a.m4s", "manifest.mpd"}},
+		},
+		{
+			name:    "dash",
+			objects: map[string]string{"manifest.mpd": `<MPD><Period><AdaptationSet><Representation id="v"><SegmentList duration="15"><SegmentURL media="a.m4s"/></SegmentList></Representation></AdaptationSet></Period></MPD>`, "a.m4s": "x"},
+			mpd:     true,
+			want:    dto.Playlist{Playlists: 1, Referenced: 1, Duration: 15},
+		},
+		{
+			name:    "no playlist",
+			objects: map[string]string{"segment_00000.ts": "x"},
+			want:    dto.Playlist{Unreferenced: []string{"segment_00000.ts"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := objectstore.NewMemoryStore("bucket")
+			for name, data := range tt.objects {
+				m.Put("root/id/"+name, []byte(data))
+			}
+			got, err := ValidatePlaylists(context.Background(), Side{Store: m, RootPrefix: "root/"}, dto.Record{ID: "id"}, tt.mpd)
+			if err != nil {
+				t.Fatalf("ValidatePlaylists() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidatePlaylists() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketBasedComparisonPlaylists(t *testing.T) {
+	side, side2, prod, temp := newSides(map[string]int{"short": 4, "ok": 2}, map[string]int{"short": 2, "ok": 2})
+	prod.Put(rootPrefix+"short/index.m3u8", []byte(mediaPlaylist(4, 9.6)))
+	temp.Put(rootPrefix2+"short/index.m3u8", []byte(mediaPlaylist(4, 9.6)))
+	prod.Put(rootPrefix+"ok/index.m3u8", []byte(mediaPlaylist(2, 15)))
+	temp.Put(rootPrefix2+"ok/index.m3u8", []byte(mediaPlaylist(2, 15)))
+
+	var out bytes.Buffer
+	rules := []Rule{{Name: "Short", Match: metric("playlist_missing2", ">", 0)}}
+	BucketBasedComparison(context.Background(), side, side2, Options{Playlists: true, Rules: rules}, log.New(&out, "", 0))
+
+	for _, want := range []string{
+		"short,38,38", // the #EXTINF sums, not 4*15 and 2*15
+		"playlist 'short': bucket2 'temp': 1 playlist(s), 0 invalid (), 4 referenced, 2 missing (segment_00002.ts, segment_00003.ts), 0 unreferenced (), 38.4 sec",
+		"Total IDs with broken playlists in temp bucket: 1",
+		`BrokenPlaylist2: ("short")`,
+		`Short: ("short")`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "playlist 'ok'") {
+		t.Errorf("valid playlist logged, got:\n%s", out.String())
+	}
+}
+
+func TestCompareAcrossBucketsManifestDuration(t *testing.T) {
+	side, side2, prod, temp := newSides(map[string]int{"id": 2}, map[string]int{"id": 2})
+	prod.Put(rootPrefix+"id/index.m3u8", []byte(mediaPlaylist(2, 9.6)))
+	temp.Put(rootPrefix2+"id/index.m3u8", []byte(mediaPlaylist(2, 9.6)))
+
+	var out bytes.Buffer
+	opts := Options{Playlists: true, Durations: ManifestDuration{}}
+	res, err := compareAcrossBuckets(context.Background(), dto.Record{ID: "id"}, side, side2, opts, log.New(&out, "", 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, out.String())
+	}
+	if res.Duration != 19.2 || res.Duration2 != 19.2 {
+		t.Errorf("durations = (%v, %v), want the #EXTINF sums (19.2, 19.2)", res.Duration, res.Duration2)
+	}
+	// The validation read the playlists, the durations reuse its sums
+	if prod.ReadCalls() != 1 || temp.ReadCalls() != 1 {
+		t.Errorf("read calls = (%d, %d), want (1, 1)", prod.ReadCalls(), temp.ReadCalls())
+	}
+}
@@ -0,0 +1,87 @@
+package compare
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"common/dto"
+)
+
+// ReadRecords reads the IDs to compare from path. The file is either one ID
+// per line (file.txt), or CSV with a header row that has an "id" column; the
+// other columns become fields for path templates, e.g.
+//
+//	id,date
+//	fc8a9074-87d7-4c08-a0cb-ed4c00e0e91d,2024/12/01
+//
+// Lines starting with "#" are comments, such as the summary list-ids appends.
+func ReadRecords(path string) ([]dto.Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	firstLine, _, _ := bytes.Cut(bytes.TrimSpace(data), []byte("\n"))
+	if bytes.Contains(firstLine, []byte(",")) {
+		return readCSVRecords(bytes.NewReader(data))
+	}
+
+	var records []dto.Record
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if id == "" || strings.HasPrefix(id, "#") {
+			continue
+		}
+		records = append(records, dto.Record{ID: id})
+	}
+	return records, scanner.Err()
+}
+
+func readCSVRecords(reader io.Reader) ([]dto.Record, error) {
+	r := csv.NewReader(reader)
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	idCol := -1
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if header[i] == "id" {
+			idCol = i
+		}
+	}
+	if idCol < 0 {
+		return nil, fmt.Errorf("csv header %v has no 'id' column", header)
+	}
+
+	var records []dto.Record
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rec := dto.Record{ID: strings.TrimSpace(row[idCol]), Fields: make(map[string]string)}
+		if rec.ID == "" {
+			continue
+		}
+		for i, value := range row {
+			if i != idCol {
+				rec.Fields[header[i]] = strings.TrimSpace(value)
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
@@ -0,0 +1,262 @@
+package compare
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"common/dto"
+)
+
+// Predicate decides whether an ID should be flagged.
+type Predicate func(r dto.Result) bool
+
+// Rule is a named Predicate; the IDs it matches are listed under Name.
+type Rule struct {
+	Name  string
+	Match Predicate
+}
+
+// Metrics a predicate can compare against a number, see ParseRule.
+var metrics = map[string]func(r dto.Result) float64{
+	"count":       func(r dto.Result) float64 { return float64(r.Count) },
+	"count2":      func(r dto.Result) float64 { return float64(r.Count2) },
+	"bytes":       func(r dto.Result) float64 { return float64(r.Bytes) },
+	"bytes2":      func(r dto.Result) float64 { return float64(r.Bytes2) },
+	"diff":        func(r dto.Result) float64 { return float64(r.Count - r.Count2) },
+	"abs_diff":    func(r dto.Result) float64 { return math.Abs(float64(r.Count - r.Count2)) },
+	"rel_diff":    func(r dto.Result) float64 { return relDiff(float64(r.Count), float64(r.Count2)) },
+	"ratio":       func(r dto.Result) float64 { return ratio(float64(r.Count), float64(r.Count2)) },
+	"bytes_diff":  func(r dto.Result) float64 { return float64(r.Bytes - r.Bytes2) },
+	"bytes_ratio": func(r dto.Result) float64 { return ratio(float64(r.Bytes), float64(r.Bytes2)) },
+	"duration":    func(r dto.Result) float64 { return r.Duration },
+	"duration2":   func(r dto.Result) float64 { return r.Duration2 },
+	"min_size":    func(r dto.Result) float64 { return float64(r.MinSize) },
+	"min_size2":   func(r dto.Result) float64 { return float64(r.MinSize2) },
+	"max_size":    func(r dto.Result) float64 { return float64(r.MaxSize) },
+	"max_size2":   func(r dto.Result) float64 { return float64(r.MaxSize2) },
+	"zero_bytes":  func(r dto.Result) float64 { return float64(r.ZeroBytes) },
+	"zero_bytes2": func(r dto.Result) float64 { return float64(r.ZeroBytes2) },
+
+	// Set when verifying, see VerifyID
+	"identical":         func(r dto.Result) float64 { return float64(r.Verification.Identical) },
+	"differing":         func(r dto.Result) float64 { return float64(r.Verification.Differing) },
+	"missing_in_source": func(r dto.Result) float64 { return float64(r.Verification.MissingInSource) },
+	"missing_in_target": func(r dto.Result) float64 { return float64(r.Verification.MissingInTarget) },
+
+	// Set when diffing names, see DiffNames
+	"only_in_source": func(r dto.Result) float64 { return float64(r.Names.OnlyInSource) },
+	"only_in_target": func(r dto.Result) float64 { return float64(r.Names.OnlyInTarget) },
+	"in_both":        func(r dto.Result) float64 { return float64(r.Names.InBoth) },
+
+	// Set when analyzing segment sequences, see AnalyzeSequence
+	"missing_segments":  func(r dto.Result) float64 { return float64(r.Sequence.MissingSegments()) },
+	"missing_segments2": func(r dto.Result) float64 { return float64(r.Sequence2.MissingSegments()) },
+	"duplicates":        func(r dto.Result) float64 { return float64(len(r.Sequence.Duplicates)) },
+	"duplicates2":       func(r dto.Result) float64 { return float64(len(r.Sequence2.Duplicates)) },
+	"out_of_order":      func(r dto.Result) float64 { return float64(len(r.Sequence.OutOfOrder)) },
+	"out_of_order2":     func(r dto.Result) float64 { return float64(len(r.Sequence2.OutOfOrder)) },
+
+	// Set when validating playlists, see ValidatePlaylists
+	"playlist_missing":   func(r dto.Result) float64 { return float64(len(r.Playlist.Missing)) },
+	"playlist_missing2":  func(r dto.Result) float64 { return float64(len(r.Playlist2.Missing)) },
+	"unreferenced":       func(r dto.Result) float64 { return float64(len(r.Playlist.Unreferenced)) },
+	"unreferenced2":      func(r dto.Result) float64 { return float64(len(r.Playlist2.Unreferenced)) },
+	"playlist_duration":  func(r dto.Result) float64 { return r.Playlist.Duration },
+	"playlist_duration2": func(r dto.Result) float64 { return r.Playlist2.Duration },
+}
+
+// relDiff is the diff between a and b in percent of the larger one.
+func relDiff(a, b float64) float64 {
+	if a == b {
+		return 0
+	}
+	return math.Abs(a-b) * 100 / math.Max(a, b)
+}
+
+// ratio is b / a; nothing against nothing is 1, something against nothing is +Inf.
+func ratio(a, b float64) float64 {
+	if a == 0 {
+		if b == 0 {
+			return 1
+		}
+		return math.Inf(1)
+	}
+	return b / a
+}
+
+var operators = map[string]func(a, b float64) bool{
+	">=": func(a, b float64) bool { return a >= b },
+	"<=": func(a, b float64) bool { return a <= b },
+	"==": func(a, b float64) bool { return a == b },
+	"!=": func(a, b float64) bool { return a != b },
+	">":  func(a, b float64) bool { return a > b },
+	"<":  func(a, b float64) bool { return a < b },
+}
+
+// metric returns a Predicate comparing a metric with value.
+func metric(name, op string, value float64) Predicate {
+	m, cmp := metrics[name], operators[op]
+	return func(r dto.Result) bool { return cmp(m(r), value) }
+}
+
+// Diff matches IDs with at least n more files in bucket1 than bucket2.
+func Diff(n int) Predicate { return metric("diff", ">=", float64(n)) }
+
+// AbsDiff matches IDs whose no. of files differs by at least n, in either direction.
+func AbsDiff(n int) Predicate { return metric("abs_diff", ">=", float64(n)) }
+
+// RelDiff matches IDs whose no. of files differs by at least pct percent of the larger count.
+func RelDiff(pct float64) Predicate { return metric("rel_diff", ">=", pct) }
+
+// RatioUnder matches IDs with fewer than x times as many files in bucket2 as in bucket1.
+func RatioUnder(x float64) Predicate { return metric("ratio", "<", x) }
+
+// BytesRatioUnder matches IDs with fewer than x times as many bytes in bucket2 as in bucket1.
+func BytesRatioUnder(x float64) Predicate { return metric("bytes_ratio", "<", x) }
+
+// TargetEmpty matches IDs with files in bucket1 but none in bucket2.
+func TargetEmpty() Predicate {
+	return func(r dto.Result) bool { return r.Count > 0 && r.Count2 == 0 }
+}
+
+// SourceEmpty matches IDs with files in bucket2 but none in bucket1.
+func SourceEmpty() Predicate {
+	return func(r dto.Result) bool { return r.Count == 0 && r.Count2 > 0 }
+}
+
+// SizeMismatch matches IDs with the same no. of files in both buckets but a different total size,
+// e.g. truncated segments.
+func SizeMismatch() Predicate {
+	return func(r dto.Result) bool { return r.Count == r.Count2 && r.Bytes != r.Bytes2 }
+}
+
+// FieldEquals matches IDs whose record field name (e.g. from the input file) is value.
+func FieldEquals(name, value string) Predicate {
+	return func(r dto.Result) bool { return r.Fields[name] == value }
+}
+
+// And matches when all ps match.
+func And(ps ...Predicate) Predicate {
+	return func(r dto.Result) bool {
+		for _, p := range ps {
+			if !p(r) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches when any of ps matches.
+func Or(ps ...Predicate) Predicate {
+	return func(r dto.Result) bool {
+		for _, p := range ps {
+			if p(r) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches when p does not.
+func Not(p Predicate) Predicate {
+	return func(r dto.Result) bool { return !p(r) }
+}
+
+// ParseRule parses a rule written as "<name>: <expr>", e.g.
+//
+//	MoreThan50: diff >= 50
+//	Truncated: abs_diff < 5 && bytes_ratio < 0.9
+//	Missing: target_empty || source_empty
+//
+// A term is target_empty, source_empty, size_mismatch, field.<name> == <value> (or !=),
+// or <metric> <op> <number> with op one of >=, >, <=, <, ==, != and metric one of
+//
+//	count, count2, bytes, bytes2, diff (count - count2), abs_diff, rel_diff (in percent
+//	of the larger count), ratio (count2 / count), bytes_diff, bytes_ratio,
+//	duration, duration2 (in sec, see DurationProvider),
+//	min_size, max_size, zero_bytes (and with a 2 suffix for bucket2),
+//	identical, differing, missing_in_source, missing_in_target (when verifying),
+//	only_in_source, only_in_target, in_both (when diffing names),
+//	missing_segments, duplicates, out_of_order (when analyzing sequences, 2 suffix for bucket2),
+//	playlist_missing, unreferenced, playlist_duration (when validating playlists, 2 suffix for bucket2).
+//
+// Terms can be negated with "!" and combined with "&&", which binds tighter than "||".
+func ParseRule(spec string) (Rule, error) {
+	name, expr, ok := strings.Cut(spec, ":")
+	name, expr = strings.TrimSpace(name), strings.TrimSpace(expr)
+	if !ok || name == "" || expr == "" {
+		return Rule{}, fmt.Errorf("rule %q: want <name>: <expr>", spec)
+	}
+	match, err := ParsePredicate(expr)
+	if err != nil {
+		return Rule{}, fmt.Errorf("rule '%s': %w", name, err)
+	}
+	return Rule{Name: name, Match: match}, nil
+}
+
+// ParsePredicate parses the expression of a rule, see ParseRule.
+func ParsePredicate(expr string) (Predicate, error) {
+	var alternatives []Predicate
+	for _, conjunction := range strings.Split(expr, "||") {
+		var all []Predicate
+		for _, term := range strings.Split(conjunction, "&&") {
+			p, err := parseTerm(strings.TrimSpace(term))
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, p)
+		}
+		alternatives = append(alternatives, And(all...))
+	}
+	return Or(alternatives...), nil
+}
+
+func parseTerm(term string) (Predicate, error) {
+	if rest, ok := strings.CutPrefix(term, "!"); ok {
+		p, err := parseTerm(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, err
+		}
+		return Not(p), nil
+	}
+	switch term {
+	case "":
+		return nil, fmt.Errorf("empty term")
+	case "target_empty":
+		return TargetEmpty(), nil
+	case "source_empty":
+		return SourceEmpty(), nil
+	case "size_mismatch":
+		return SizeMismatch(), nil
+	}
+
+	fields := strings.Fields(term)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("term %q: want <metric> <op> <value>", term)
+	}
+	name, op, value := fields[0], fields[1], fields[2]
+	if field, ok := strings.CutPrefix(name, "field."); ok {
+		switch op {
+		case "==":
+			return FieldEquals(field, value), nil
+		case "!=":
+			return Not(FieldEquals(field, value)), nil
+		}
+		return nil, fmt.Errorf("term %q: fields only support == and !=", term)
+	}
+	if _, ok := metrics[name]; !ok {
+		return nil, fmt.Errorf("term %q: unknown metric '%s'", term, name)
+	}
+	if _, ok := operators[op]; !ok {
+		return nil, fmt.Errorf("term %q: unknown operator '%s'", term, op)
+	}
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("term %q: invalid number '%s'", term, value)
+	}
+	return metric(name, op, v), nil
+}
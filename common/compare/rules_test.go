@@ -0,0 +1,114 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"common/dto"
+)
+
+func TestParseRule(t *testing.T) {
+	res := func(count, count2 int, bytes, bytes2 int64) dto.Result {
+		return dto.Result{Record: dto.Record{ID: "id", Fields: map[string]string{"tier": "gold"}}, Count: count, Count2: count2, Bytes: bytes, Bytes2: bytes2}
+	}
+
+	tests := []struct {
+		spec    string
+		result  dto.Result
+		want    bool
+		wantErr bool
+	}{
+		{spec: "MoreThan50: diff >= 50", result: res(80, 20, 0, 0), want: true},
+		{spec: "MoreThan50: diff >= 50", result: res(20, 80, 0, 0), want: false},
+		{spec: "Off: abs_diff >= 50", result: res(20, 80, 0, 0), want: true},
+		{spec: "Rel: rel_diff > 10", result: res(100, 89, 0, 0), want: true},
+		{spec: "Rel: rel_diff > 10", result: res(100, 91, 0, 0), want: false},
+		{spec: "Gone: target_empty", result: res(3, 0, 0, 0), want: true},
+		{spec: "Gone: target_empty", result: res(0, 0, 0, 0), want: false},
+		{spec: "New: source_empty", result: res(0, 3, 0, 0), want: true},
+		{spec: "Low: ratio < 0.5", result: res(10, 4, 0, 0), want: true},
+		{spec: "Low: ratio < 0.5", result: res(0, 0, 0, 0), want: false},
+		{spec: "Truncated: abs_diff < 5 && bytes_ratio < 0.9", result: res(10, 10, 1000, 500), want: true},
+		{spec: "Truncated: abs_diff < 5 && bytes_ratio < 0.9", result: res(10, 10, 1000, 1000), want: false},
+		{spec: "Missing: target_empty || source_empty", result: res(0, 7, 0, 0), want: true},
+		{spec: "Partial: !target_empty && count2 < 3", result: res(5, 2, 0, 0), want: true},
+		{spec: "Size: size_mismatch", result: res(10, 10, 1000, 999), want: true},
+		{spec: "Size: size_mismatch", result: res(10, 9, 1000, 999), want: false},
+		{spec: "Empty: zero_bytes2 > 0", result: dto.Result{ZeroBytes2: 1}, want: true},
+		{spec: "Gold: field.tier == gold && diff > 0", result: res(5, 2, 0, 0), want: true},
+		{spec: "NotGold: field.tier != gold", result: res(5, 2, 0, 0), want: false},
+		{spec: "no expression", wantErr: true},
+		{spec: ": diff > 1", wantErr: true},
+		{spec: "Bad: files > 1", wantErr: true},
+		{spec: "Bad: diff => 1", wantErr: true},
+		{spec: "Bad: diff > many", wantErr: true},
+		{spec: "Bad: diff > 1 &&", wantErr: true},
+		{spec: "Bad: field.tier > 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			rule, err := ParseRule(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := rule.Match(tt.result); got != tt.want {
+				t.Errorf("Match(%+v) = %v, want %v", tt.result, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketBasedComparisonRules(t *testing.T) {
+	side, side2, _, _ := newSides(
+		map[string]int{"a": 80, "b": 5, "c": 10, "d": 0},
+		map[string]int{"a": 20, "b": 0, "c": 10, "d": 4},
+	)
+	var rules []Rule
+	for _, spec := range []string{"Gone: target_empty", "Off: abs_diff >= 4", "Low: ratio < 0.5"} {
+		rule, err := ParseRule(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, rule)
+	}
+
+	var out bytes.Buffer
+	BucketBasedComparison(context.Background(), side, side2, Options{Rules: rules}, log.New(&out, "", 0))
+
+	for _, want := range []string{
+		`Gone: ()`, // b has no prefix in temp, so it is not compared
+		`Off: ("a", "d")`,
+		`Low: ("a")`,
+		"a,1200,300",
+		"d,0,60",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "MoreThan50") {
+		t.Errorf("default rule used alongside custom rules, got:\n%s", out.String())
+	}
+}
+
+func TestFlagResult(t *testing.T) {
+	rules := []Rule{{Name: "A", Match: Diff(1)}, {Name: "B", Match: TargetEmpty()}, {Name: "C", Match: SourceEmpty()}}
+	badIds := make(map[string][]string)
+	res := dto.Result{Record: dto.Record{ID: "x"}, Count: 3}
+	flagResult(&res, rules, badIds)
+
+	if want := []string{"A", "B"}; !reflect.DeepEqual(res.Rules, want) {
+		t.Errorf("Rules = %v, want %v", res.Rules, want)
+	}
+	if want := map[string][]string{"A": {"x"}, "B": {"x"}}; !reflect.DeepEqual(badIds, want) {
+		t.Errorf("badIds = %v, want %v", badIds, want)
+	}
+}
@@ -0,0 +1,209 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+// DefaultSequencePattern takes the last number before the extension, e.g. 42 from segment_00042.ts.
+var DefaultSequencePattern = regexp.MustCompile(`(\d+)\.[^./]+$`)
+
+// Sequence findings listed in the summary, see classifySequence.
+const (
+	gaps          = "Gaps"       // missing sequence numbers
+	duplicates    = "Duplicates" // sequence numbers in more than one object
+	outOfOrder    = "OutOfOrder" // segments uploaded before a lower one
+	bucket2Suffix = "2"          // suffix of the findings in bucket2
+)
+
+// ParseSequencePattern compiles a pattern for the sequence number in an object name.
+// A group named "seq" (or the first group) holds the number.
+func ParseSequencePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if re.NumSubexp() == 0 {
+		return nil, fmt.Errorf("pattern '%s': no group for the sequence number", pattern)
+	}
+	return re, nil
+}
+
+// segment is an object with a sequence number.
+type segment struct {
+	seq     int64
+	updated time.Time
+}
+
+// AnalyzeSequence lists the objects of rec on side, parses the sequence number
+// from each name with pattern and reports the gaps, duplicates and segments
+// uploaded before a lower one.
+func AnalyzeSequence(ctx context.Context, side Side, rec dto.Record, pattern *regexp.Regexp) (dto.Sequence, error) {
+	v := newSequenceVisitor(pattern)
+	if err := visitSide(ctx, side, rec, v); err != nil {
+		return dto.Sequence{}, err
+	}
+	return v.sequence(), nil
+}
+
+// sequenceVisitor collects the segments of an ID on one side, see AnalyzeSequence.
+type sequenceVisitor struct {
+	pattern  *regexp.Regexp
+	group    int
+	segments []segment
+	unparsed int
+}
+
+func newSequenceVisitor(pattern *regexp.Regexp) *sequenceVisitor {
+	group := 1
+	if i := pattern.SubexpIndex("seq"); i > 0 {
+		group = i
+	}
+	return &sequenceVisitor{pattern: pattern, group: group}
+}
+
+func (v *sequenceVisitor) visit(rel string, attrs *objectstore.ObjectAttrs) error {
+	match := v.pattern.FindStringSubmatch(rel)
+	if match == nil {
+		v.unparsed++
+		return nil
+	}
+	seq, err := strconv.ParseInt(match[v.group], 10, 64)
+	if err != nil {
+		v.unparsed++
+		return nil
+	}
+	v.segments = append(v.segments, segment{seq: seq, updated: attrs.Updated})
+	return nil
+}
+
+// sequence analyzes the segments collected.
+func (v *sequenceVisitor) sequence() dto.Sequence {
+	seq := analyzeSegments(v.segments)
+	seq.Unparsed = v.unparsed
+	return seq
+}
+
+func analyzeSegments(segments []segment) dto.Sequence {
+	var seq dto.Sequence
+	if len(segments) == 0 {
+		return seq
+	}
+	sort.SliceStable(segments, func(i, j int) bool {
+		if segments[i].seq != segments[j].seq {
+			return segments[i].seq < segments[j].seq
+		}
+		return segments[i].updated.Before(segments[j].updated)
+	})
+
+	seq.First, seq.Last, seq.Segments = segments[0].seq, segments[len(segments)-1].seq, len(segments)
+	var latest time.Time // latest upload of a lower sequence number
+	for i, s := range segments {
+		if i > 0 {
+			prev := segments[i-1].seq
+			switch {
+			case s.seq == prev:
+				if n := len(seq.Duplicates); n == 0 || seq.Duplicates[n-1] != s.seq {
+					seq.Duplicates = append(seq.Duplicates, s.seq)
+				}
+				continue
+			case s.seq > prev+1:
+				seq.Missing = append(seq.Missing, dto.Range{From: prev + 1, To: s.seq - 1})
+			}
+		}
+		if s.updated.Before(latest) {
+			seq.OutOfOrder = append(seq.OutOfOrder, s.seq)
+		}
+		if s.updated.After(latest) {
+			latest = s.updated
+		}
+	}
+	return seq
+}
+
+// classifySequence returns the sequence findings for res, with a 2 suffix for bucket2.
+func classifySequence(res dto.Result) []string {
+	var found []string
+	for _, s := range []struct {
+		seq    dto.Sequence
+		suffix string
+	}{{res.Sequence, ""}, {res.Sequence2, bucket2Suffix}} {
+		if len(s.seq.Missing) > 0 {
+			found = append(found, gaps+s.suffix)
+		}
+		if len(s.seq.Duplicates) > 0 {
+			found = append(found, duplicates+s.suffix)
+		}
+		if len(s.seq.OutOfOrder) > 0 {
+			found = append(found, outOfOrder+s.suffix)
+		}
+	}
+	return found
+}
+
+// sequenceOK reports whether a sequence has no gaps, duplicates or late uploads.
+func sequenceOK(s dto.Sequence) bool {
+	return len(s.Missing) == 0 && len(s.Duplicates) == 0 && len(s.OutOfOrder) == 0
+}
+
+// logSequence logs the sequence problems of res in each bucket that has any.
+func logSequence(logger *log.Logger, res dto.Result, bucket, bucket2 string) {
+	for _, s := range []struct {
+		seq    dto.Sequence
+		label  string
+		bucket string
+	}{{res.Sequence, "bucket1", bucket}, {res.Sequence2, "bucket2", bucket2}} {
+		if sequenceOK(s.seq) {
+			continue
+		}
+		logger.Printf("sequence '%s': %s '%s': segments %d-%d, %d missing (%s), %d duplicate(s) (%s), %d out of order (%s)\n",
+			res.ID, s.label, s.bucket, s.seq.First, s.seq.Last,
+			s.seq.MissingSegments(), formatRanges(s.seq.Missing),
+			len(s.seq.Duplicates), formatSeqs(s.seq.Duplicates),
+			len(s.seq.OutOfOrder), formatSeqs(s.seq.OutOfOrder))
+	}
+}
+
+// formatRanges formats ranges as e.g. "5-6, 9".
+func formatRanges(ranges []dto.Range) string {
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = strconv.FormatInt(r.From, 10)
+		if r.To != r.From {
+			parts[i] += "-" + strconv.FormatInt(r.To, 10)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatSeqs(seqs []int64) string {
+	parts := make([]string, len(seqs))
+	for i, s := range seqs {
+		parts[i] = strconv.FormatInt(s, 10)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printSequence logs the no. of IDs with each sequence finding, then the IDs themselves.
+func printSequence(logger *log.Logger, findings dto.Counts) {
+	logger.Printf("Total IDs with missing segments in prod bucket: %d\n", len(findings[gaps]))
+	logger.Printf("Total IDs with missing segments in temp bucket: %d\n", len(findings[gaps+bucket2Suffix]))
+	logger.Printf("Total IDs with duplicate segments in prod bucket: %d\n", len(findings[duplicates]))
+	logger.Printf("Total IDs with duplicate segments in temp bucket: %d\n", len(findings[duplicates+bucket2Suffix]))
+	logger.Printf("Total IDs with segments uploaded out of order in prod bucket: %d\n", len(findings[outOfOrder]))
+	logger.Printf("Total IDs with segments uploaded out of order in temp bucket: %d\n", len(findings[outOfOrder+bucket2Suffix]))
+	for _, name := range []string{gaps, gaps + bucket2Suffix, duplicates, duplicates + bucket2Suffix, outOfOrder, outOfOrder + bucket2Suffix} {
+		if len(findings[name]) > 0 {
+			logger.Printf("%s: %s\n", name, quoteIDs(findings[name]))
+		}
+	}
+}
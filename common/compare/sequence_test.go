@@ -0,0 +1,126 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+func TestAnalyzeSequence(t *testing.T) {
+	base := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		objects []string
+		late    map[string]bool // uploaded after all the others
+		pattern *regexp.Regexp
+		want    dto.Sequence
+	}{
+		{
+			name:    "complete",
+			objects: []string{"segment_00000.ts", "segment_00001.ts", "segment_00002.ts"},
+			want:    dto.Sequence{First: 0, Last: 2, Segments: 3},
+		},
+		{
+			name:    "gaps",
+			objects: []string{"segment_00001.ts", "segment_00004.ts", "segment_00005.ts", "segment_00007.ts", "index.m3u8"},
+			want:    dto.Sequence{First: 1, Last: 7, Segments: 4, Unparsed: 1, Missing: []dto.Range{{From: 2, To: 3}, {From: 6, To: 6}}},
+		},
+		{
+			name:    "duplicates",
+			objects: []string{"segment_1.ts", "segment_00001.ts", "retry/segment_00002.ts", "segment_00002.ts", "segment_00002.tmp"},
+			want:    dto.Sequence{First: 1, Last: 2, Segments: 5, Duplicates: []int64{1, 2}},
+		},
+		{
+			name:    "out of order",
+			objects: []string{"segment_00000.ts", "segment_00001.ts", "segment_00002.ts", "segment_00003.ts"},
+			late:    map[string]bool{"segment_00001.ts": true},
+			want:    dto.Sequence{First: 0, Last: 3, Segments: 4, OutOfOrder: []int64{2, 3}},
+		},
+		{
+			name:    "named group",
+			objects: []string{"chunk-3-720p.ts", "chunk-5-720p.ts"},
+			pattern: regexp.MustCompile(`chunk-(?P<seq>\d+)-(\d+)p`),
+			want:    dto.Sequence{First: 3, Last: 5, Segments: 2, Missing: []dto.Range{{From: 4, To: 4}}},
+		},
+		{
+			name: "empty",
+			want: dto.Sequence{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := objectstore.NewMemoryStore("bucket")
+			for i, name := range tt.objects {
+				m.Put("root/id/"+name, []byte("x"))
+				updated := base.Add(time.Duration(i) * time.Second)
+				if tt.late[name] {
+					updated = base.Add(time.Hour)
+				}
+				m.SetUpdated("root/id/"+name, updated)
+			}
+			pattern := tt.pattern
+			if pattern == nil {
+				pattern = DefaultSequencePattern
+			}
+
+			got, err := AnalyzeSequence(context.Background(), Side{Store: m, RootPrefix: "root/"}, dto.Record{ID: "id"}, pattern)
+			if err != nil {
+				t.Fatalf("AnalyzeSequence() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AnalyzeSequence() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSequencePattern(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		wantErr bool
+	}{
+		{pattern: `(\d+)\.ts$`},
+		{pattern: `seg(?P<seq>\d+)`},
+		{pattern: `\d+\.ts$`, wantErr: true},
+		{pattern: `(\d+`, wantErr: true},
+	} {
+		if _, err := ParseSequencePattern(tt.pattern); (err != nil) != tt.wantErr {
+			t.Errorf("ParseSequencePattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBucketBasedComparisonSequence(t *testing.T) {
+	side, side2, _, temp := newSides(map[string]int{"ok": 5, "holes": 10}, map[string]int{"ok": 5})
+	for _, i := range []int{0, 1, 2, 5, 9} {
+		temp.Put(fmt.Sprintf("%sholes/segment_%05d.ts", rootPrefix2, i), []byte("x"))
+	}
+
+	var out bytes.Buffer
+	BucketBasedComparison(context.Background(), side, side2, Options{Sequence: DefaultSequencePattern}, log.New(&out, "", 0))
+
+	for _, want := range []string{
+		"sequence 'holes': bucket2 'temp': segments 0-9, 5 missing (3-4, 6-8), 0 duplicate(s) (), 0 out of order ()",
+		"Total IDs with missing segments in prod bucket: 0",
+		"Total IDs with missing segments in temp bucket: 1",
+		`Gaps2: ("holes")`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "sequence 'ok'") || strings.Contains(out.String(), "bucket1 'prod': segments") {
+		t.Errorf("sequence without problems logged, got:\n%s", out.String())
+	}
+}
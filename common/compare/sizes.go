@@ -0,0 +1,47 @@
+package compare
+
+import (
+	"log"
+
+	"common/dto"
+)
+
+// Size findings listed in the summary, see classifySizes.
+const (
+	sizeMismatch = "SizeMismatch" // same no. of files, different total size
+	zeroBytes    = "ZeroBytes"    // empty files in bucket1
+	zeroBytes2   = "ZeroBytes2"   // empty files in bucket2
+)
+
+// classifySizes returns the size findings for res.
+func classifySizes(res dto.Result) []string {
+	var found []string
+	if SizeMismatch()(res) {
+		found = append(found, sizeMismatch)
+	}
+	if res.ZeroBytes > 0 {
+		found = append(found, zeroBytes)
+	}
+	if res.ZeroBytes2 > 0 {
+		found = append(found, zeroBytes2)
+	}
+	return found
+}
+
+// logSizes logs the sizes of the files for res on both sides.
+func logSizes(logger *log.Logger, res dto.Result, bucket, bucket2 string) {
+	logger.Printf("livestream '%s': bucket1 '%s': %d byte(s) (min %d, max %d, %d empty): bucket2 '%s': %d byte(s) (min %d, max %d, %d empty)\n",
+		res.ID, bucket, res.Bytes, res.MinSize, res.MaxSize, res.ZeroBytes, bucket2, res.Bytes2, res.MinSize2, res.MaxSize2, res.ZeroBytes2)
+}
+
+// printSizes logs the no. of IDs with each size finding, then the IDs themselves.
+func printSizes(logger *log.Logger, sizes dto.Counts) {
+	logger.Printf("Total IDs with same files but different size in prod bucket and temp bucket: %d\n", len(sizes[sizeMismatch]))
+	logger.Printf("Total IDs with zero-byte files in prod bucket: %d\n", len(sizes[zeroBytes]))
+	logger.Printf("Total IDs with zero-byte files in temp bucket: %d\n", len(sizes[zeroBytes2]))
+	for _, name := range []string{sizeMismatch, zeroBytes, zeroBytes2} {
+		if len(sizes[name]) > 0 {
+			logger.Printf("%s: %s\n", name, quoteIDs(sizes[name]))
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package compare
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"common/dto"
+)
+
+// templateField matches placeholders such as {id} or {date}.
+var templateField = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+// ExpandTemplate fills in a path template such as "CompositePreProcessing/v4/{id}/segments/"
+// or "{date}/{id}/". {id} is the record's ID, other placeholders come from its fields.
+func ExpandTemplate(template string, rec dto.Record) (string, error) {
+	var missing string
+	path := templateField.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		if name == "id" {
+			return rec.ID
+		}
+		value, ok := rec.Fields[name]
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("template '%s': no field '%s' for ID '%s'", template, missing, rec.ID)
+	}
+	return path, nil
+}
+
+// ValidateTemplate checks that a template contains {id} and has balanced braces.
+func ValidateTemplate(template string) error {
+	if strings.ContainsAny(templateField.ReplaceAllString(template, ""), "{}") {
+		return fmt.Errorf("template '%s': unbalanced or invalid placeholder", template)
+	}
+	if !strings.Contains(template, "{id}") {
+		return fmt.Errorf("template '%s': missing {id}", template)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"common/dto"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	rec := dto.Record{ID: "abc", Fields: map[string]string{"date": "2024/12/01"}}
+
+	tests := []struct {
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{template: "CompositePreProcessing/v4/{id}/segments/", want: "CompositePreProcessing/v4/abc/segments/"},
+		{template: "{date}/{id}/", want: "2024/12/01/abc/"},
+		{template: "CompleteLivestreamRecording/{id}/compositeRecording", want: "CompleteLivestreamRecording/abc/compositeRecording"},
+		{template: "{region}/{id}/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got, err := ExpandTemplate(tt.template, rec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  bool
+	}{
+		{template: "{date}/{id}/"},
+		{template: "root/{date}/", wantErr: true},
+		{template: "root/{id/", wantErr: true},
+		{template: "root/{id}/{}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateTemplate(tt.template); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateTemplate(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReadRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []dto.Record
+		wantErr bool
+	}{
+		{
+			name:    "one id per line",
+			content: "a\n\n b \n# Retries: 0 of 0 call(s) retried\n",
+			want:    []dto.Record{{ID: "a"}, {ID: "b"}},
+		},
+		{
+			name:    "csv with fields",
+			content: "date, id\n2024/12/01, a\n2024/12/02,b\n# Retries: 0 of 0 call(s) retried\n",
+			want: []dto.Record{
+				{ID: "a", Fields: map[string]string{"date": "2024/12/01"}},
+				{ID: "b", Fields: map[string]string{"date": "2024/12/02"}},
+			},
+		},
+		{
+			name:    "csv without id column",
+			content: "name,date\na,2024/12/01\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ids")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadRecords(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadRecords() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadRecords() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
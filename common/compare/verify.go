@@ -0,0 +1,144 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+// Verification findings listed in the summary, see classifyVerification.
+const (
+	identical       = "Identical"       // every object is in both buckets with the same content
+	differing       = "Differing"       // objects whose content differs
+	missingInSource = "MissingInSource" // objects only in bucket2
+	missingInTarget = "MissingInTarget" // objects only in bucket1
+)
+
+// VerifyID matches the objects of rec on both sides by their name relative to
+// the ID's prefix and compares the checksums stored with them. Objects without
+// a checksum both sides can compare are read and hashed.
+func VerifyID(ctx context.Context, rec dto.Record, side, side2 Side) (dto.Verification, error) {
+	var v dto.Verification
+	err := mergeListings(ctx, rec, side, side2, func(rel string, attrs, attrs2 *objectstore.ObjectAttrs) error {
+		return verifyObject(ctx, side.Store, attrs, side2.Store, attrs2, &v)
+	})
+	return v, err
+}
+
+// verifyObject counts an object found with attrs in bucket1 and attrs2 in bucket2
+// (nil where missing) in v.
+func verifyObject(ctx context.Context, store objectstore.Store, attrs *objectstore.ObjectAttrs, store2 objectstore.Store, attrs2 *objectstore.ObjectAttrs, v *dto.Verification) error {
+	switch {
+	case attrs2 == nil:
+		v.MissingInTarget++
+		return nil
+	case attrs == nil:
+		v.MissingInSource++
+		return nil
+	}
+	same, err := sameContent(ctx, store, attrs, store2, attrs2, v)
+	if err != nil {
+		return err
+	}
+	if same {
+		v.Identical++
+	} else {
+		v.Differing++
+	}
+	return nil
+}
+
+// sameContent compares two objects by size, then by a checksum both have stored,
+// hashing the side(s) missing one.
+func sameContent(ctx context.Context, store objectstore.Store, attrs *objectstore.ObjectAttrs, store2 objectstore.Store, attrs2 *objectstore.ObjectAttrs, v *dto.Verification) (bool, error) {
+	if attrs.Size != attrs2.Size {
+		return false, nil
+	}
+	sum, sum2 := attrs.Checksums, attrs2.Checksums
+	same, ok := matchChecksums(sum, sum2)
+
+	// Hash the side without a full-content checksum first, it is the one the other cannot be compared with
+	var err error
+	if !ok && sum.CRC32C == "" && sum.MD5 == "" {
+		if sum, err = hashObject(ctx, store, attrs.Name, v); err != nil {
+			return false, err
+		}
+		same, ok = matchChecksums(sum, sum2)
+	}
+	if !ok {
+		if sum2, err = hashObject(ctx, store2, attrs2.Name, v); err != nil {
+			return false, err
+		}
+		same, ok = matchChecksums(sum, sum2)
+	}
+	if !ok {
+		return false, fmt.Errorf("object '%s': no comparable checksum", attrs.Name)
+	}
+	return same, nil
+}
+
+func hashObject(ctx context.Context, store objectstore.Store, name string, v *dto.Verification) (objectstore.Checksums, error) {
+	v.Hashed++
+	return objectstore.HashObject(ctx, store, name)
+}
+
+// matchChecksums compares the checksums both sides have; ok is false if there is none.
+// Multipart MD5s depend on the part sizes, so only a match is conclusive.
+func matchChecksums(sum, sum2 objectstore.Checksums) (same, ok bool) {
+	switch {
+	case sum.CRC32C != "" && sum2.CRC32C != "":
+		return sum.CRC32C == sum2.CRC32C, true
+	case sum.MD5 != "" && sum2.MD5 != "":
+		return sum.MD5 == sum2.MD5, true
+	case sum.MultipartMD5 != "" && sum.MultipartMD5 == sum2.MultipartMD5:
+		return true, true
+	}
+	return false, false
+}
+
+// classifyVerification returns the verification findings for res.
+func classifyVerification(res dto.Result) []string {
+	v := res.Verification
+	if allIdentical(v) {
+		return []string{identical}
+	}
+	var found []string
+	if v.Differing > 0 {
+		found = append(found, differing)
+	}
+	if v.MissingInSource > 0 {
+		found = append(found, missingInSource)
+	}
+	if v.MissingInTarget > 0 {
+		found = append(found, missingInTarget)
+	}
+	return found
+}
+
+// allIdentical reports whether every object was found on both sides with the same content.
+func allIdentical(v dto.Verification) bool {
+	return v.Differing == 0 && v.MissingInSource == 0 && v.MissingInTarget == 0
+}
+
+// logVerification logs the verification counts for res.
+func logVerification(logger *log.Logger, res dto.Result, bucket, bucket2 string) {
+	v := res.Verification
+	logger.Printf("verify '%s': %d identical, %d differing, %d missing in bucket1 '%s', %d missing in bucket2 '%s' (%d hashed)\n",
+		res.ID, v.Identical, v.Differing, v.MissingInSource, bucket, v.MissingInTarget, bucket2, v.Hashed)
+}
+
+// printVerification logs the no. of IDs with each verification finding, then the IDs themselves.
+func printVerification(logger *log.Logger, findings dto.Counts) {
+	logger.Printf("Total IDs with identical objects in prod bucket and temp bucket: %d\n", len(findings[identical]))
+	logger.Printf("Total IDs with differing objects in prod bucket and temp bucket: %d\n", len(findings[differing]))
+	logger.Printf("Total IDs with objects missing in prod bucket: %d\n", len(findings[missingInSource]))
+	logger.Printf("Total IDs with objects missing in temp bucket: %d\n", len(findings[missingInTarget]))
+	for _, name := range []string{differing, missingInSource, missingInTarget} {
+		if len(findings[name]) > 0 {
+			logger.Printf("%s: %s\n", name, quoteIDs(findings[name]))
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"common/dto"
+	"common/objectstore"
+)
+
+func TestVerifyID(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name          string
+		files, files2 map[string]string
+		noChecksums   bool // bucket1 keeps no checksums, like the local filesystem
+		noChecksums2  bool
+		fault         *objectstore.Fault
+		want          dto.Verification
+		wantErr       error
+	}{
+		{
+			name:   "identical",
+			files:  map[string]string{"0.ts": "aaaa", "1.ts": "bbbb"},
+			files2: map[string]string{"0.ts": "aaaa", "1.ts": "bbbb"},
+			want:   dto.Verification{Identical: 2},
+		},
+		{
+			name:   "same size different content",
+			files:  map[string]string{"0.ts": "aaaa", "1.ts": "bbbb"},
+			files2: map[string]string{"0.ts": "aaaa", "1.ts": "bbbc"},
+			want:   dto.Verification{Identical: 1, Differing: 1},
+		},
+		{
+			name:   "different size",
+			files:  map[string]string{"0.ts": "aaaa"},
+			files2: map[string]string{"0.ts": "aa"},
+			want:   dto.Verification{Differing: 1},
+		},
+		{
+			name:   "missing on either side",
+			files:  map[string]string{"0.ts": "a", "1.ts": "b"},
+			files2: map[string]string{"1.ts": "b", "2.ts": "c"},
+			want:   dto.Verification{Identical: 1, MissingInSource: 1, MissingInTarget: 1},
+		},
+		{
+			name:        "hashes the side without checksums",
+			files:       map[string]string{"0.ts": "aaaa", "1.ts": "bbbb"},
+			files2:      map[string]string{"0.ts": "aaaa", "1.ts": "bbbc"},
+			noChecksums: true,
+			want:        dto.Verification{Identical: 1, Differing: 1, Hashed: 2},
+		},
+		{
+			name:         "hashes both sides without checksums",
+			files:        map[string]string{"0.ts": "aaaa"},
+			files2:       map[string]string{"0.ts": "aaaa"},
+			noChecksums:  true,
+			noChecksums2: true,
+			want:         dto.Verification{Identical: 1, Hashed: 2},
+		},
+		{
+			name:        "read error while hashing",
+			files:       map[string]string{"0.ts": "aaaa"},
+			files2:      map[string]string{"0.ts": "aaaa"},
+			noChecksums: true,
+			fault:       &objectstore.Fault{Err: errBoom, Times: 2}, // let the count and the listing through
+			wantErr:     errBoom,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prod := objectstore.NewMemoryStore("prod")
+			temp := objectstore.NewMemoryStore("temp")
+			for name, data := range tt.files {
+				prod.Put(rootPrefix+"id/"+name, []byte(data))
+			}
+			for name, data := range tt.files2 {
+				temp.Put("2024/12/01/id/"+name, []byte(data))
+			}
+			prod.NoChecksums, temp.NoChecksums = tt.noChecksums, tt.noChecksums2
+			side := Side{Store: prod, RootPrefix: rootPrefix}
+			side2 := Side{Store: temp, Template: "2024/12/01/{id}/"}
+			if tt.fault != nil {
+				prod.InjectFault(rootPrefix+"id/0.ts", *tt.fault)
+			}
+
+			got, err := VerifyID(context.Background(), dto.Record{ID: "id"}, side, side2)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("VerifyID() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyID() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyID() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchChecksums(t *testing.T) {
+	tests := []struct {
+		name           string
+		sum, sum2      objectstore.Checksums
+		wantSame, want bool
+	}{
+		{name: "crc32c", sum: objectstore.Checksums{CRC32C: "a", MD5: "x"}, sum2: objectstore.Checksums{CRC32C: "a", MD5: "y"}, wantSame: true, want: true},
+		{name: "md5 when one has no crc32c", sum: objectstore.Checksums{CRC32C: "a", MD5: "x"}, sum2: objectstore.Checksums{MD5: "y"}, wantSame: false, want: true},
+		{name: "equal multipart md5", sum: objectstore.Checksums{MultipartMD5: "m-2"}, sum2: objectstore.Checksums{MultipartMD5: "m-2"}, wantSame: true, want: true},
+		{name: "different multipart md5 is inconclusive", sum: objectstore.Checksums{MultipartMD5: "m-2"}, sum2: objectstore.Checksums{MultipartMD5: "n-3"}},
+		{name: "nothing in common", sum: objectstore.Checksums{CRC32C: "a"}, sum2: objectstore.Checksums{MD5: "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			same, ok := matchChecksums(tt.sum, tt.sum2)
+			if same != tt.wantSame || ok != tt.want {
+				t.Errorf("matchChecksums() = (%v, %v), want (%v, %v)", same, ok, tt.wantSame, tt.want)
+			}
+		})
+	}
+}
+
+func TestBucketBasedComparisonVerify(t *testing.T) {
+	side, side2, prod, temp := newSides(map[string]int{"same": 3, "corrupt": 3, "renamed": 2}, map[string]int{"same": 3, "corrupt": 3})
+	temp.Put(rootPrefix2+"corrupt/segment_00001.ts", []byte("y"))
+	temp.Put(rootPrefix2+"renamed/seg_0.ts", []byte("x"))
+	temp.Put(rootPrefix2+"renamed/seg_1.ts", []byte("x"))
+	prod.NoChecksums = true
+
+	var out bytes.Buffer
+	BucketBasedComparison(context.Background(), side, side2, Options{Verify: true, Rules: []Rule{{Name: "Corrupt", Match: metric("differing", ">", 0)}}}, log.New(&out, "", 0))
+
+	for _, want := range []string{
+		"verify 'corrupt': 2 identical, 1 differing, 0 missing in bucket1 'prod', 0 missing in bucket2 'temp' (3 hashed)",
+		"verify 'renamed': 0 identical, 0 differing, 2 missing in bucket1 'prod', 2 missing in bucket2 'temp' (0 hashed)",
+		"Total IDs with identical objects in prod bucket and temp bucket: 1",
+		"Total IDs with differing objects in prod bucket and temp bucket: 1",
+		"Total IDs with objects missing in prod bucket: 1",
+		`Differing: ("corrupt")`,
+		`MissingInTarget: ("renamed")`,
+		`Corrupt: ("corrupt")`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q, got:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "verify 'same'") {
+		t.Errorf("identical ID logged, got:\n%s", out.String())
+	}
+}
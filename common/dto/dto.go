@@ -0,0 +1,85 @@
+package dto
+
+// Counts holds the IDs in each bin of a histogram, keyed by bin name.
+type Counts map[string][]string
+
+// Record is one ID to compare, with any extra fields used to fill in path templates.
+type Record struct {
+	ID     string
+	Fields map[string]string
+}
+
+// Durations are the durations (in sec) of a livestream in each bucket, as found by the comparison
+// (see compare.DurationProvider: from the no. of files, the playlists, metadata or upload times).
+type Durations struct {
+	Prod int64
+	Temp int64
+}
+
+// Result is what was found for one ID in both buckets.
+type Result struct {
+	Record
+	Count, Count2         int          // no. of files in bucket1 and bucket2
+	Bytes, Bytes2         int64        // total size of the files
+	MinSize, MinSize2     int64        // size of the smallest file
+	MaxSize, MaxSize2     int64        // size of the largest file
+	ZeroBytes, ZeroBytes2 int          // no. of empty files
+	Verification          Verification // set when verifying checksums
+	Names                 NameDiff     // set when diffing object names
+	Sequence, Sequence2   Sequence     // set when analyzing segment sequence numbers
+	Playlist, Playlist2   Playlist     // set when validating playlists
+	Duration, Duration2   float64      // length of the recording in sec, see compare.DurationProvider
+	Rules                 []string     // names of the flag rules the ID tripped
+}
+
+// Verification counts how the objects of one ID compare, matched by their name relative to its prefix.
+type Verification struct {
+	Identical       int
+	Differing       int
+	MissingInSource int // only in bucket2
+	MissingInTarget int // only in bucket1
+	Hashed          int // objects read to compute a checksum neither side stored
+}
+
+// NameDiff counts the objects of one ID by where their name (relative to its prefix) was found.
+type NameDiff struct {
+	OnlyInSource int // only in bucket1
+	OnlyInTarget int // only in bucket2
+	InBoth       int
+}
+
+// Sequence describes the segment sequence numbers of one ID in one bucket.
+type Sequence struct {
+	First, Last int64   // lowest and highest sequence number
+	Segments    int     // objects with a sequence number
+	Unparsed    int     // objects without one
+	Missing     []Range // gaps between First and Last
+	Duplicates  []int64 // sequence numbers found in more than one object
+	OutOfOrder  []int64 // sequence numbers uploaded before a lower one
+}
+
+// MissingSegments returns the no. of sequence numbers in the gaps.
+func (s Sequence) MissingSegments() int64 {
+	var n int64
+	for _, r := range s.Missing {
+		n += r.To - r.From + 1
+	}
+	return n
+}
+
+// Range is an inclusive range of sequence numbers.
+type Range struct {
+	From, To int64
+}
+
+// Playlist describes the playlists (HLS .m3u8, DASH .mpd) of one ID in one bucket
+// and how their references match the objects found.
+type Playlist struct {
+	Playlists    int      // playlists found
+	Invalid      []string // playlists that could not be parsed, with the reason
+	Referenced   int      // distinct objects referenced by them
+	External     int      // references to absolute URLs, not checked
+	Missing      []string // referenced but not found, relative to the ID's prefix
+	Unreferenced []string // found but not referenced
+	Duration     float64  // sum of the segment durations (#EXTINF) in sec, of the longest rendition
+}
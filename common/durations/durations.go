@@ -0,0 +1,157 @@
+// Package durations joins the durations found by a comparison (see
+// compare.DurationProvider) with the livestream durations recorded in Spanner.
+package durations
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+
+	"common/dto"
+	"common/retry"
+)
+
+const query = `
+		SELECT livestream_id, (ended_at - created_at) / 1000 AS duration_in_sec
+		FROM livestream
+		WHERE livestream_id IN UNNEST(@livestreamIds)
+		AND created_at > @createdAtTimestamp
+	`
+
+// DatabaseName returns the fully qualified name of a Spanner database.
+func DatabaseName(projectID, instanceID, databaseID string) string {
+	return fmt.Sprintf("projects/%s/instances/%s/databases/%s", projectID, instanceID, databaseID)
+}
+
+// ReadFile parses path into a map for easy lookup. Each line is
+// "<id>,<prod duration in sec>,<temp duration in sec>"; invalid lines are logged and skipped.
+func ReadFile(path string, logger *log.Logger) (map[string]dto.Durations, error) {
+	inputFile, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer inputFile.Close()
+
+	durationMap := make(map[string]dto.Durations)
+	scanner := bufio.NewScanner(inputFile)
+	for scanner.Scan() {
+		line := scanner.Text()
+		parts := strings.Split(line, ",")
+		if len(parts) != 3 {
+			logger.Printf("Skipping invalid line: %s", line)
+			continue
+		}
+		livestreamID := strings.TrimSpace(parts[0])
+		var durProd int64
+		if _, err := fmt.Sscanf(strings.TrimSpace(parts[1]), "%d", &durProd); err != nil {
+			logger.Printf("Skipping line with invalid dur_prod: %s", line)
+			continue
+		}
+		var durTemp int64
+		if _, err := fmt.Sscanf(strings.TrimSpace(parts[2]), "%d", &durTemp); err != nil {
+			logger.Printf("Skipping line with invalid dur_temp: %s", line)
+			continue
+		}
+		durationMap[livestreamID] = dto.Durations{Prod: durProd, Temp: durTemp}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return durationMap, nil
+}
+
+// Report looks up the livestreams in durationMap created after createdAtTimestamp
+// (unix ms) and writes their recorded duration next to the file-based ones to w.
+// The query is retried on transient errors (see IsRetryable) and counted in
+// metrics, which may be nil.
+func Report(ctx context.Context, client *spanner.Client, durationMap map[string]dto.Durations, createdAtTimestamp int64, w io.Writer, metrics *retry.Metrics) error {
+	livestreamIDs := make([]string, 0, len(durationMap))
+	for id := range durationMap {
+		livestreamIDs = append(livestreamIDs, id)
+	}
+	sort.Strings(livestreamIDs)
+
+	stmt := spanner.Statement{
+		SQL: query,
+		Params: map[string]interface{}{
+			"livestreamIds":      livestreamIDs,
+			"createdAtTimestamp": createdAtTimestamp,
+		},
+	}
+
+	// Read all rows before writing any, so a retried query does not repeat them
+	var rows []recorded
+	err := retry.Call(ctx, IsRetryable, metrics, func() error {
+		var err error
+		rows, err = queryDurations(ctx, client, stmt)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(w)
+	for _, row := range rows {
+		duration, exists := durationMap[row.livestreamID]
+		if exists {
+			if err := writeRow(writer, row.livestreamID, row.durationInSec, duration); err != nil {
+				return err
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+// recorded is the duration of a livestream as recorded in Spanner.
+type recorded struct {
+	livestreamID  string
+	durationInSec float64
+}
+
+// queryDurations runs stmt and returns its rows.
+func queryDurations(ctx context.Context, client *spanner.Client, stmt spanner.Statement) ([]recorded, error) {
+	iter := client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	var rows []recorded
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate through results: %w", err)
+		}
+
+		// Extract results
+		var r recorded
+		if err := row.Columns(&r.livestreamID, &r.durationInSec); err != nil {
+			return nil, fmt.Errorf("failed to parse row: %w", err)
+		}
+		rows = append(rows, r)
+	}
+}
+
+// IsRetryable reports whether a Spanner read failed with a transient error:
+// Unavailable or ResourceExhausted.
+func IsRetryable(err error) bool {
+	code := spanner.ErrCode(err)
+	return code == codes.Unavailable || code == codes.ResourceExhausted
+}
+
+// writeRow writes the recorded duration of a livestream next to the ones found in
+// each bucket (see compare.DurationProvider) and how far they are off.
+func writeRow(w io.Writer, livestreamID string, durationInSec float64, duration dto.Durations) error {
+	_, err := fmt.Fprintf(w, "id: %s, total_duration_in_sec: %v, prod_duration_in_sec: %d, temp_duration_in_sec: %d, prod_diff_in_sec: %.3f, temp_diff_in_sec: %.3f\n",
+		livestreamID, durationInSec, duration.Prod, duration.Temp, float64(duration.Prod)-durationInSec, float64(duration.Temp)-durationInSec)
+	return err
+}
@@ -0,0 +1,69 @@
+package durations
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"common/dto"
+)
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input.txt")
+	content := "a,3930,1530\n b , 1290 , 330 \nc,12\nd,x,1\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+
+	got, err := ReadFile(path, log.New(&out, "", 0))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	want := map[string]dto.Durations{"a": {Prod: 3930, Temp: 1530}, "b": {Prod: 1290, Temp: 330}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadFile() = %v, want %v", got, want)
+	}
+	for _, skipped := range []string{"Skipping invalid line: c,12", "Skipping line with invalid dur_prod: d,x,1"} {
+		if !strings.Contains(out.String(), skipped) {
+			t.Errorf("output missing %q, got:\n%s", skipped, out.String())
+		}
+	}
+}
+
+func TestWriteRow(t *testing.T) {
+	var out bytes.Buffer
+	if err := writeRow(&out, "a", 3600.5, dto.Durations{Prod: 3600, Temp: 1800}); err != nil {
+		t.Fatal(err)
+	}
+	want := "id: a, total_duration_in_sec: 3600.5, prod_duration_in_sec: 3600, temp_duration_in_sec: 1800, prod_diff_in_sec: -0.500, temp_diff_in_sec: -1800.500\n"
+	if out.String() != want {
+		t.Errorf("writeRow() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: status.Error(codes.Unavailable, "session closed"), want: true},
+		{err: fmt.Errorf("failed to iterate through results: %w", status.Error(codes.ResourceExhausted, "quota")), want: true},
+		{err: status.Error(codes.InvalidArgument, "bad query")},
+		{err: errors.New("boom")},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
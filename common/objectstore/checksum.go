@@ -0,0 +1,47 @@
+package objectstore
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+)
+
+// Checksums are the hashes a provider stores with an object, base64-encoded as
+// the providers return them. Fields the provider did not store are empty.
+type Checksums struct {
+	CRC32C       string // Castagnoli CRC32, big-endian as in the GCS JSON API
+	MD5          string // of the whole content
+	MultipartMD5 string // OCI opc-multipart-md5, "<md5 of the part md5s>-<no. of parts>"; only equal for the same part layout
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// encodeCRC32C encodes a CRC32C the way GCS does.
+func encodeCRC32C(sum uint32) string {
+	return base64.StdEncoding.EncodeToString(binary.BigEndian.AppendUint32(nil, sum))
+}
+
+// checksumsOf computes the CRC32C and MD5 of data.
+func checksumsOf(data []byte) Checksums {
+	sum := md5.Sum(data)
+	return Checksums{CRC32C: encodeCRC32C(crc32.Checksum(data, crc32cTable)), MD5: base64.StdEncoding.EncodeToString(sum[:])}
+}
+
+// HashObject streams an object and computes its CRC32C and MD5, for stores
+// that keep no checksum (or one the other side cannot compare against).
+func HashObject(ctx context.Context, store Store, name string) (Checksums, error) {
+	r, err := store.Read(ctx, name)
+	if err != nil {
+		return Checksums{}, err
+	}
+	defer r.Close()
+
+	crc, md := crc32.New(crc32cTable), md5.New()
+	if _, err := io.Copy(io.MultiWriter(crc, md), r); err != nil {
+		return Checksums{}, err
+	}
+	return Checksums{CRC32C: encodeCRC32C(crc.Sum32()), MD5: base64.StdEncoding.EncodeToString(md.Sum(nil))}, nil
+}
@@ -0,0 +1,262 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// FSStore is a Store backed by a local directory, e.g. a bucket dumped to disk
+// with `gsutil rsync` or `oci os object bulk-download`. Object names are the
+// slash-separated file paths relative to the root, so directories act as prefixes.
+type FSStore struct {
+	root string
+}
+
+// NewFSStore returns a Store rooted at dir.
+func NewFSStore(dir string) *FSStore {
+	return &FSStore{root: dir}
+}
+
+func (s *FSStore) Name() string {
+	return s.root
+}
+
+func (s *FSStore) List(ctx context.Context, prefix string) ObjectIterator {
+	names, err := s.walk(ctx, prefix)
+	return &fsIterator{store: s, names: names, err: err}
+}
+
+func (s *FSStore) ListPrefixes(ctx context.Context, prefix, delimiter string) ([]string, error) {
+	if delimiter != "/" {
+		return s.listPrefixesByName(ctx, prefix, delimiter)
+	}
+
+	// The common prefixes are the non-empty directories at the level of prefix
+	dir, base := splitPrefix(prefix)
+	entries, err := s.readDir(dir, base)
+	if err != nil {
+		return nil, err
+	}
+	var prefixes []string
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !entry.IsDir() {
+			continue
+		}
+		name := path.Join(dir, entry.Name())
+		if ok, err := s.hasFiles(name); err != nil {
+			return nil, err
+		} else if ok {
+			prefixes = append(prefixes, name+delimiter)
+		}
+	}
+	return prefixes, nil
+}
+
+// listPrefixesByName returns the common prefixes for a delimiter other than the
+// path separator, which needs the name of every file under prefix.
+func (s *FSStore) listPrefixesByName(ctx context.Context, prefix, delimiter string) ([]string, error) {
+	names, err := s.walk(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var prefixes []string
+
+	// names are sorted, so equal common prefixes are adjacent
+	for _, name := range names {
+		rest := strings.TrimPrefix(name, prefix)
+		idx := strings.Index(rest, delimiter)
+		if delimiter == "" || idx < 0 {
+			continue
+		}
+		commonPrefix := prefix + rest[:idx+len(delimiter)]
+		if len(prefixes) == 0 || prefixes[len(prefixes)-1] != commonPrefix {
+			prefixes = append(prefixes, commonPrefix)
+		}
+	}
+	return prefixes, nil
+}
+
+func (s *FSStore) Stat(ctx context.Context, name string) (*ObjectAttrs, error) {
+	info, err := os.Stat(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return nil, ErrObjectNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectAttrs{Name: name, Size: info.Size(), Updated: info.ModTime()}, nil
+}
+
+func (s *FSStore) Read(ctx context.Context, name string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrObjectNotExist
+	}
+	return f, err
+}
+
+func (s *FSStore) Write(ctx context.Context, name string, r io.Reader) error {
+	dst := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temp file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (s *FSStore) Delete(ctx context.Context, name string) error {
+	err := os.Remove(s.path(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrObjectNotExist
+	}
+	return err
+}
+
+// path maps an object name onto a file path under the root.
+func (s *FSStore) path(name string) string {
+	return filepath.Join(s.root, filepath.FromSlash(name))
+}
+
+// splitPrefix splits prefix into the deepest directory it fully names and the
+// start of the names in that directory: "a/b/" -> "a/b", "", "a/b" -> "a", "b",
+// "" -> ".", "".
+func splitPrefix(prefix string) (dir, base string) {
+	dir = path.Dir(prefix + "x")
+	if dir == "." {
+		return dir, prefix
+	}
+	return dir, prefix[len(dir)+1:]
+}
+
+// readDir returns the entries of dir whose name starts with base, sorted by
+// name, or none if dir does not exist.
+func (s *FSStore) readDir(dir, base string) ([]fs.DirEntry, error) {
+	entries, err := os.ReadDir(s.path(dir))
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	matching := entries[:0]
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), base) {
+			matching = append(matching, entry)
+		}
+	}
+	return matching, nil
+}
+
+// hasFiles reports whether there is a file anywhere under the directory dir.
+func (s *FSStore) hasFiles(dir string) (bool, error) {
+	found := false
+	err := filepath.WalkDir(s.path(dir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			found = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
+
+// walk returns the sorted names of all files whose name starts with prefix.
+// Only the entries of the deepest directory fully named by prefix that match
+// the rest of it are read, so listing "root/id1" does not walk "root/id2".
+func (s *FSStore) walk(ctx context.Context, prefix string) ([]string, error) {
+	dir, base := splitPrefix(prefix)
+	entries, err := s.readDir(dir, base)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if !entry.IsDir() {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			names = append(names, name)
+			continue
+		}
+		err := filepath.WalkDir(s.path(name), func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(s.root, p)
+			if err != nil {
+				return err
+			}
+			names = append(names, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// WalkDir orders entries per directory, buckets order by full name
+	sort.Strings(names)
+	return names, nil
+}
+
+type fsIterator struct {
+	store *FSStore
+	names []string
+	err   error
+}
+
+func (i *fsIterator) Next() (*ObjectAttrs, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	if len(i.names) == 0 {
+		return nil, Done
+	}
+	name := i.names[0]
+	i.names = i.names[1:]
+
+	info, err := os.Stat(i.store.path(name))
+	if err != nil {
+		return nil, err
+	}
+	// Files have no custom metadata, so the listing is complete
+	return &ObjectAttrs{Name: name, Size: info.Size(), Updated: info.ModTime(), Metadata: map[string]string{}}, nil
+}
+
+func (i *fsIterator) Stop() {}
@@ -0,0 +1,86 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFSStoreListing(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"root/a/1.ts", "root/a/2.ts", "root/ab/1.ts", "root/b/seg/1.ts", "root.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "root", "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := NewFSStore(dir)
+	ctx := context.Background()
+
+	counts := map[string]int{"": 5, "root/": 4, "root/a": 3, "root/a/": 2, "root/b/seg/": 1, "root": 5, "root.txt/": 0, "missing/": 0}
+	for prefix, want := range counts {
+		got, err := CountFiles(ctx, s, prefix)
+		if err != nil {
+			t.Fatalf("CountFiles(%q) error = %v", prefix, err)
+		}
+		if got != want {
+			t.Errorf("CountFiles(%q) = %d, want %d", prefix, got, want)
+		}
+	}
+
+	prefixes, err := s.ListPrefixes(ctx, "root/", "/")
+	if err != nil {
+		t.Fatalf("ListPrefixes() error = %v", err)
+	}
+	if want := []string{"root/a/", "root/ab/", "root/b/"}; !reflect.DeepEqual(prefixes, want) {
+		t.Errorf("ListPrefixes() = %v, want %v", prefixes, want)
+	}
+
+	// Only the directories at the level of the prefix that match it are read
+	prefixes, err = s.ListPrefixes(ctx, "root/a", "/")
+	if err != nil {
+		t.Fatalf("ListPrefixes() error = %v", err)
+	}
+	if want := []string{"root/a/", "root/ab/"}; !reflect.DeepEqual(prefixes, want) {
+		t.Errorf("ListPrefixes(%q) = %v, want %v", "root/a", prefixes, want)
+	}
+}
+
+func TestFSStoreObjects(t *testing.T) {
+	s := NewFSStore(t.TempDir())
+	ctx := context.Background()
+
+	if err := s.Write(ctx, "a/b.ts", strings.NewReader("hello")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	r, err := s.Read(ctx, "a/b.ts")
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != "hello" {
+		t.Errorf("Read() = %q, want %q", data, "hello")
+	}
+
+	if _, err := s.Stat(ctx, "a"); !errors.Is(err, ErrObjectNotExist) {
+		t.Errorf("Stat() on directory error = %v, want %v", err, ErrObjectNotExist)
+	}
+	if err := s.Delete(ctx, "a/b.ts"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Read(ctx, "a/b.ts"); !errors.Is(err, ErrObjectNotExist) {
+		t.Errorf("Read() after Delete error = %v, want %v", err, ErrObjectNotExist)
+	}
+}
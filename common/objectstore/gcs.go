@@ -0,0 +1,161 @@
+package objectstore
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+
+	"common/retry"
+)
+
+// GCSStore is a Store backed by a Google Cloud Storage bucket.
+type GCSStore struct {
+	client *storage.Client
+	bucket string
+}
+
+// NewGCSStore returns a Store for bucket using an existing GCS client.
+// The client is shared and must be closed by the caller.
+func NewGCSStore(client *storage.Client, bucket string) *GCSStore {
+	return &GCSStore{client: client, bucket: bucket}
+}
+
+func (s *GCSStore) Name() string {
+	return s.bucket
+}
+
+func (s *GCSStore) List(ctx context.Context, prefix string) ObjectIterator {
+	query := &storage.Query{Prefix: prefix}
+	return &gcsIterator{it: s.client.Bucket(s.bucket).Objects(ctx, query)}
+}
+
+// gcsPageSize is the no. of objects ListPage asks for, the most a request returns.
+const gcsPageSize = 1000
+
+// ListPage returns a page of up to gcsPageSize objects, see PageLister.
+func (s *GCSStore) ListPage(ctx context.Context, prefix, startAfter string) ([]*ObjectAttrs, bool, error) {
+	// StartOffset is inclusive, startAfter itself is dropped below
+	query := &storage.Query{Prefix: prefix, StartOffset: startAfter}
+	var objects []*storage.ObjectAttrs
+	token, err := iterator.NewPager(s.client.Bucket(s.bucket).Objects(ctx, query), gcsPageSize, "").NextPage(&objects)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list objects: %w", err)
+	}
+	page := make([]*ObjectAttrs, 0, len(objects))
+	for _, attrs := range objects {
+		if attrs.Name > startAfter {
+			page = append(page, gcsAttrs(attrs))
+		}
+	}
+	return page, token != "", nil
+}
+
+func (s *GCSStore) ListPrefixes(ctx context.Context, prefix, delimiter string) ([]string, error) {
+	query := &storage.Query{Prefix: prefix, Delimiter: delimiter}
+	it := s.client.Bucket(s.bucket).Objects(ctx, query)
+	var prefixes []string
+
+	for {
+		objAttrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		// Objects directly under prefix are returned alongside the synthetic prefix entries
+		if objAttrs.Prefix != "" {
+			prefixes = append(prefixes, objAttrs.Prefix)
+		}
+	}
+	return prefixes, nil
+}
+
+func (s *GCSStore) Stat(ctx context.Context, name string) (*ObjectAttrs, error) {
+	attrs, err := s.client.Bucket(s.bucket).Object(name).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, ErrObjectNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gcsAttrs(attrs), nil
+}
+
+func (s *GCSStore) Read(ctx context.Context, name string) (io.ReadCloser, error) {
+	r, err := s.client.Bucket(s.bucket).Object(name).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, ErrObjectNotExist
+	}
+	return r, err
+}
+
+func (s *GCSStore) Write(ctx context.Context, name string, r io.Reader) error {
+	w := s.client.Bucket(s.bucket).Object(name).NewWriter(ctx)
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (s *GCSStore) Delete(ctx context.Context, name string) error {
+	err := s.client.Bucket(s.bucket).Object(name).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return ErrObjectNotExist
+	}
+	return err
+}
+
+type gcsIterator struct {
+	it *storage.ObjectIterator
+}
+
+func (i *gcsIterator) Next() (*ObjectAttrs, error) {
+	attrs, err := i.it.Next()
+	if err == iterator.Done {
+		return nil, Done
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gcsAttrs(attrs), nil
+}
+
+func (i *gcsIterator) Stop() {}
+
+func gcsAttrs(attrs *storage.ObjectAttrs) *ObjectAttrs {
+	// GCS always keeps a CRC32C, the MD5 is missing for composite objects
+	checksums := Checksums{CRC32C: encodeCRC32C(attrs.CRC32C)}
+	if len(attrs.MD5) > 0 {
+		checksums.MD5 = base64.StdEncoding.EncodeToString(attrs.MD5)
+	}
+	// Listings return the custom metadata too, keep it non-nil for objects without any
+	metadata := attrs.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	return &ObjectAttrs{
+		Name:      attrs.Name,
+		Size:      attrs.Size,
+		Updated:   attrs.Updated,
+		Metadata:  metadata,
+		Checksums: checksums,
+	}
+}
+
+// IsRetryableGCS reports whether a GCS call failed with a transient error: HTTP 429
+// or 5xx, gRPC Unavailable or ResourceExhausted, or a connection cut short.
+func IsRetryableGCS(err error) bool {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return retry.IsRetryableStatus(apiErr.Code)
+	}
+	return retry.IsRetryableGRPC(err) || errors.Is(err, io.ErrUnexpectedEOF)
+}
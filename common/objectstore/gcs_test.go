@@ -0,0 +1,22 @@
+package objectstore
+
+import "testing"
+
+func TestGCSEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		emulator bool
+		want     string
+	}{
+		{endpoint: "localhost:4443", emulator: true, want: "http://localhost:4443/storage/v1/"},
+		{endpoint: "storage.example.com:443", want: "https://storage.example.com:443/storage/v1/"},
+		{endpoint: "http://localhost:4443/storage/v1/", want: "http://localhost:4443/storage/v1/"},
+		{endpoint: "https://storage.example.com/storage/v1/", emulator: true, want: "https://storage.example.com/storage/v1/"},
+	}
+
+	for _, tt := range tests {
+		if got := gcsEndpoint(tt.endpoint, tt.emulator); got != tt.want {
+			t.Errorf("gcsEndpoint(%q, %v) = %q, want %q", tt.endpoint, tt.emulator, got, tt.want)
+		}
+	}
+}
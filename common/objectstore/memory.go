@@ -0,0 +1,269 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fault is injected into calls that touch a name under a given prefix.
+type Fault struct {
+	Latency time.Duration // delay before the call returns; cut short if the context is done
+	Err     error         // error returned by the call, nil to only add latency
+	Times   int           // fail only the first Times calls (transient); 0 fails every call (permanent)
+}
+
+// MemoryStore is an in-memory Store for tests. It supports prefix/delimiter
+// listing, pagination and per-prefix fault injection.
+type MemoryStore struct {
+	// PageSize is the no. of objects returned per list call; 0 returns everything in one call.
+	PageSize int
+	// NoChecksums leaves ObjectAttrs.Checksums empty, like stores that keep none.
+	NoChecksums bool
+
+	name      string
+	mu        sync.Mutex
+	objects   map[string]memoryObject
+	faults    map[string]*Fault
+	listCalls int
+	readCalls int
+}
+
+type memoryObject struct {
+	data     []byte
+	updated  time.Time
+	metadata map[string]string
+}
+
+// NewMemoryStore returns an empty MemoryStore called name.
+func NewMemoryStore(name string) *MemoryStore {
+	return &MemoryStore{
+		name:    name,
+		objects: make(map[string]memoryObject),
+		faults:  make(map[string]*Fault),
+	}
+}
+
+// Put stores an object with the given contents.
+func (m *MemoryStore) Put(name string, data []byte) {
+	m.PutWithMetadata(name, data, nil)
+}
+
+// PutWithMetadata stores an object with the given contents and custom metadata.
+func (m *MemoryStore) PutWithMetadata(name string, data []byte, metadata map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[name] = memoryObject{data: data, updated: time.Now(), metadata: metadata}
+}
+
+// SetUpdated changes the modification time of an object, e.g. to simulate uploads out of order.
+func (m *MemoryStore) SetUpdated(name string, updated time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if o, ok := m.objects[name]; ok {
+		o.updated = updated
+		m.objects[name] = o
+	}
+}
+
+// InjectFault makes every call touching a name under prefix behave as described by f.
+// When several prefixes match, the longest one wins.
+func (m *MemoryStore) InjectFault(prefix string, f Fault) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults[prefix] = &f
+}
+
+// ClearFaults removes all injected faults.
+func (m *MemoryStore) ClearFaults() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults = make(map[string]*Fault)
+}
+
+// ListCalls returns the no. of list pages served so far.
+func (m *MemoryStore) ListCalls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.listCalls
+}
+
+// ReadCalls returns the no. of objects read so far.
+func (m *MemoryStore) ReadCalls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.readCalls
+}
+
+func (m *MemoryStore) Name() string {
+	return m.name
+}
+
+func (m *MemoryStore) List(ctx context.Context, prefix string) ObjectIterator {
+	return &pageIterator{ctx: ctx, lister: m, prefix: prefix}
+}
+
+func (m *MemoryStore) ListPrefixes(ctx context.Context, prefix, delimiter string) ([]string, error) {
+	var prefixes []string
+	it := m.List(ctx, prefix)
+	defer it.Stop()
+
+	// Names come back sorted, so equal common prefixes are adjacent
+	for {
+		attrs, err := it.Next()
+		if err == Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rest := strings.TrimPrefix(attrs.Name, prefix)
+		idx := strings.Index(rest, delimiter)
+		if delimiter == "" || idx < 0 {
+			continue
+		}
+		commonPrefix := prefix + rest[:idx+len(delimiter)]
+		if len(prefixes) == 0 || prefixes[len(prefixes)-1] != commonPrefix {
+			prefixes = append(prefixes, commonPrefix)
+		}
+	}
+	return prefixes, nil
+}
+
+func (m *MemoryStore) Stat(ctx context.Context, name string) (*ObjectAttrs, error) {
+	if err := m.fault(ctx, name); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.objects[name]; !ok {
+		return nil, ErrObjectNotExist
+	}
+	return m.attrs(name), nil
+}
+
+func (m *MemoryStore) Read(ctx context.Context, name string) (io.ReadCloser, error) {
+	if err := m.fault(ctx, name); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readCalls++
+
+	obj, ok := m.objects[name]
+	if !ok {
+		return nil, ErrObjectNotExist
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+func (m *MemoryStore) Write(ctx context.Context, name string, r io.Reader) error {
+	if err := m.fault(ctx, name); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.Put(name, data)
+	return nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, name string) error {
+	if err := m.fault(ctx, name); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.objects[name]; !ok {
+		return ErrObjectNotExist
+	}
+	delete(m.objects, name)
+	return nil
+}
+
+// fault applies the fault registered for the longest prefix of name, if any.
+func (m *MemoryStore) fault(ctx context.Context, name string) error {
+	m.mu.Lock()
+	var match string
+	var f *Fault
+	for prefix, candidate := range m.faults {
+		if strings.HasPrefix(name, prefix) && (f == nil || len(prefix) > len(match)) {
+			match, f = prefix, candidate
+		}
+	}
+	if f == nil {
+		m.mu.Unlock()
+		return nil
+	}
+	latency, err := f.Latency, f.Err
+	if f.Times > 0 {
+		f.Times--
+		if f.Times == 0 {
+			// Transient fault used up, later calls succeed
+			delete(m.faults, match)
+		}
+	}
+	m.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// ListPage returns up to PageSize objects under prefix that come after startAfter, see PageLister.
+func (m *MemoryStore) ListPage(ctx context.Context, prefix, startAfter string) ([]*ObjectAttrs, bool, error) {
+	if err := m.fault(ctx, prefix); err != nil {
+		return nil, false, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listCalls++
+
+	var names []string
+	for name := range m.objects {
+		if strings.HasPrefix(name, prefix) && name > startAfter {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	more := false
+	if m.PageSize > 0 && len(names) > m.PageSize {
+		names, more = names[:m.PageSize], true
+	}
+	page := make([]*ObjectAttrs, len(names))
+	for i, name := range names {
+		page[i] = m.attrs(name)
+	}
+	return page, more, nil
+}
+
+// attrs returns the attributes of the object called name; m.mu must be held.
+func (m *MemoryStore) attrs(name string) *ObjectAttrs {
+	o := m.objects[name]
+	attrs := &ObjectAttrs{
+		Name:     name,
+		Size:     int64(len(o.data)),
+		Updated:  o.updated,
+		Metadata: o.metadata,
+	}
+	if attrs.Metadata == nil {
+		attrs.Metadata = map[string]string{}
+	}
+	if !m.NoChecksums {
+		attrs.Checksums = checksumsOf(o.data)
+	}
+	return attrs
+}
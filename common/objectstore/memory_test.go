@@ -0,0 +1,219 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore() *MemoryStore {
+	m := NewMemoryStore("bucket")
+	for _, name := range []string{
+		"root/a/1.ts",
+		"root/a/2.ts",
+		"root/b/1.ts",
+		"root/b/seg/2.ts",
+		"root/c.txt",
+		"other/x.ts",
+	} {
+		m.Put(name, []byte(name))
+	}
+	return m
+}
+
+func TestMemoryStoreCountFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageSize int
+		prefix   string
+		want     int
+		calls    int
+	}{
+		{name: "all objects single page", prefix: "", want: 6, calls: 1},
+		{name: "prefix", prefix: "root/b", want: 2, calls: 1},
+		{name: "missing prefix", prefix: "nope/", want: 0, calls: 1},
+		{name: "paginated", pageSize: 2, prefix: "root/", want: 5, calls: 3},
+		{name: "page size equals count", pageSize: 5, prefix: "root/", want: 5, calls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestStore()
+			m.PageSize = tt.pageSize
+
+			got, err := CountFiles(context.Background(), m, tt.prefix)
+			if err != nil {
+				t.Fatalf("CountFiles() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("CountFiles() = %d, want %d", got, tt.want)
+			}
+			if m.ListCalls() != tt.calls {
+				t.Errorf("ListCalls() = %d, want %d", m.ListCalls(), tt.calls)
+			}
+		})
+	}
+}
+
+func TestPrefixStats(t *testing.T) {
+	m := NewMemoryStore("bucket")
+	m.Put("id/0.ts", []byte("xxxx"))
+	m.Put("id/1.ts", []byte("xx"))
+	m.Put("id/2.ts", nil)
+	m.Put("id/3.ts", []byte("xxxxxx"))
+
+	tests := []struct {
+		prefix string
+		want   Stats
+	}{
+		{prefix: "id/", want: Stats{Count: 4, Bytes: 12, MinSize: 0, MaxSize: 6, ZeroBytes: 1}},
+		{prefix: "id/1", want: Stats{Count: 1, Bytes: 2, MinSize: 2, MaxSize: 2}},
+		{prefix: "nope/", want: Stats{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got, err := PrefixStats(context.Background(), m, tt.prefix)
+			if err != nil {
+				t.Fatalf("PrefixStats() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("PrefixStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreListPrefixes(t *testing.T) {
+	m := newTestStore()
+	m.PageSize = 1
+
+	got, err := m.ListPrefixes(context.Background(), "root/", "/")
+	if err != nil {
+		t.Fatalf("ListPrefixes() error = %v", err)
+	}
+	want := []string{"root/a/", "root/b/"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListPrefixes() = %v, want %v", got, want)
+	}
+}
+
+func TestMemoryStoreFaults(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name    string
+		prefix  string
+		fault   Fault
+		calls   int
+		wantErr []error
+	}{
+		{name: "permanent", prefix: "root/a", fault: Fault{Err: errBoom}, calls: 3, wantErr: []error{errBoom, errBoom, errBoom}},
+		{name: "transient", prefix: "root/a", fault: Fault{Err: errBoom, Times: 2}, calls: 3, wantErr: []error{errBoom, errBoom, nil}},
+		{name: "other prefix untouched", prefix: "root/b", fault: Fault{Err: errBoom}, calls: 1, wantErr: []error{nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestStore()
+			m.InjectFault(tt.prefix, tt.fault)
+
+			for i := 0; i < tt.calls; i++ {
+				_, err := CountFiles(context.Background(), m, "root/a/")
+				if !errors.Is(err, tt.wantErr[i]) {
+					t.Errorf("call %d: error = %v, want %v", i, err, tt.wantErr[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreLongestFaultPrefixWins(t *testing.T) {
+	errOuter, errInner := errors.New("outer"), errors.New("inner")
+	m := newTestStore()
+	m.InjectFault("root/", Fault{Err: errOuter})
+	m.InjectFault("root/a/", Fault{Err: errInner})
+
+	if _, err := m.Stat(context.Background(), "root/a/1.ts"); !errors.Is(err, errInner) {
+		t.Errorf("Stat(root/a/1.ts) error = %v, want %v", err, errInner)
+	}
+	if _, err := m.Stat(context.Background(), "root/b/1.ts"); !errors.Is(err, errOuter) {
+		t.Errorf("Stat(root/b/1.ts) error = %v, want %v", err, errOuter)
+	}
+}
+
+func TestMemoryStoreLatencyHonoursContext(t *testing.T) {
+	m := newTestStore()
+	m.InjectFault("root/", Fault{Latency: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := CountFiles(ctx, m, "root/")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CountFiles() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestMemoryStoreObjects(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore("bucket")
+
+	if _, err := m.Stat(ctx, "a"); !errors.Is(err, ErrObjectNotExist) {
+		t.Fatalf("Stat() on missing object error = %v, want %v", err, ErrObjectNotExist)
+	}
+	if err := m.Write(ctx, "a", strings.NewReader("hello")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	attrs, err := m.Stat(ctx, "a")
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if attrs.Size != 5 {
+		t.Errorf("Stat().Size = %d, want 5", attrs.Size)
+	}
+	if err := m.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := m.Delete(ctx, "a"); !errors.Is(err, ErrObjectNotExist) {
+		t.Errorf("second Delete() error = %v, want %v", err, ErrObjectNotExist)
+	}
+}
+
+func TestHashObject(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore("bucket")
+	m.Put("empty", nil)
+	m.Put("a", []byte("hello"))
+
+	got, err := HashObject(ctx, m, "empty")
+	if err != nil {
+		t.Fatalf("HashObject() error = %v", err)
+	}
+	if want := (Checksums{CRC32C: "AAAAAA==", MD5: "1B2M2Y8AsgTpgAmY7PhCfg=="}); got != want {
+		t.Errorf("HashObject(empty) = %+v, want %+v", got, want)
+	}
+
+	// The stored checksums and the streamed ones agree
+	got, err = HashObject(ctx, m, "a")
+	if err != nil {
+		t.Fatalf("HashObject() error = %v", err)
+	}
+	attrs, err := m.Stat(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != attrs.Checksums || got.MD5 != "XUFAKrxLKna5cZ2REBfFkg==" {
+		t.Errorf("HashObject(a) = %+v, Stat().Checksums = %+v", got, attrs.Checksums)
+	}
+
+	m.NoChecksums = true
+	if attrs, _ := m.Stat(ctx, "a"); attrs.Checksums != (Checksums{}) {
+		t.Errorf("Stat().Checksums = %+v with NoChecksums", attrs.Checksums)
+	}
+	if _, err := HashObject(ctx, m, "missing"); !errors.Is(err, ErrObjectNotExist) {
+		t.Errorf("HashObject(missing) error = %v, want %v", err, ErrObjectNotExist)
+	}
+}
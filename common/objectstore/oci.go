@@ -0,0 +1,234 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+
+	"common/retry"
+)
+
+// ociListFields asks ListObjects for more than just the object name.
+const ociListFields = "name,size,timeCreated,timeModified,md5"
+
+// OCIStore is a Store backed by an OCI Object Storage bucket.
+type OCIStore struct {
+	client    objectstorage.ObjectStorageClient
+	namespace string
+	bucket    string
+
+	// PageSize is the max no. of objects per ListObjects call; 0 uses the service default (1000).
+	PageSize int
+}
+
+// NewOCIStore returns a Store for bucket in namespace using an existing OCI client.
+func NewOCIStore(client objectstorage.ObjectStorageClient, namespace, bucket string) *OCIStore {
+	return &OCIStore{client: client, namespace: namespace, bucket: bucket}
+}
+
+func (s *OCIStore) Name() string {
+	return s.bucket
+}
+
+func (s *OCIStore) List(ctx context.Context, prefix string) ObjectIterator {
+	return &pageIterator{ctx: ctx, lister: s, prefix: prefix}
+}
+
+// ListPage returns a page of up to PageSize objects, see PageLister.
+func (s *OCIStore) ListPage(ctx context.Context, prefix, startAfter string) ([]*ObjectAttrs, bool, error) {
+	fields := ociListFields
+	request := objectstorage.ListObjectsRequest{
+		NamespaceName: &s.namespace,
+		BucketName:    &s.bucket,
+		Prefix:        &prefix,
+		Fields:        &fields,
+		Limit:         s.limit(),
+	}
+	if startAfter != "" {
+		request.StartAfter = &startAfter
+	}
+
+	response, err := s.client.ListObjects(ctx, request)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list objects: %w", err)
+	}
+	page := make([]*ObjectAttrs, len(response.Objects))
+	for i, obj := range response.Objects {
+		page[i] = ociAttrs(obj)
+	}
+	return page, response.NextStartWith != nil, nil
+}
+
+func (s *OCIStore) ListPrefixes(ctx context.Context, prefix, delimiter string) ([]string, error) {
+	request := objectstorage.ListObjectsRequest{
+		NamespaceName: &s.namespace,
+		BucketName:    &s.bucket,
+		Prefix:        &prefix,
+		Delimiter:     &delimiter,
+		Limit:         s.limit(),
+	}
+	var prefixes []string
+
+	// Follow NextStartWith until the listing is exhausted
+	for {
+		response, err := s.client.ListObjects(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		prefixes = append(prefixes, response.Prefixes...)
+
+		next, err := nextStart(request.Start, response.NextStartWith)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			break
+		}
+		request.Start = next
+	}
+	return prefixes, nil
+}
+
+func (s *OCIStore) Stat(ctx context.Context, name string) (*ObjectAttrs, error) {
+	request := objectstorage.HeadObjectRequest{
+		NamespaceName: &s.namespace,
+		BucketName:    &s.bucket,
+		ObjectName:    &name,
+	}
+
+	response, err := s.client.HeadObject(ctx, request)
+	if err != nil {
+		return nil, ociError(err)
+	}
+
+	attrs := &ObjectAttrs{Name: name, Metadata: response.OpcMeta}
+	if response.ContentLength != nil {
+		attrs.Size = *response.ContentLength
+	}
+	if response.LastModified != nil {
+		attrs.Updated = response.LastModified.Time
+	}
+	if response.ContentMd5 != nil {
+		attrs.Checksums.MD5 = *response.ContentMd5
+	}
+	if response.OpcMultipartMd5 != nil {
+		attrs.Checksums.MultipartMD5 = *response.OpcMultipartMd5
+	}
+	return attrs, nil
+}
+
+func (s *OCIStore) Read(ctx context.Context, name string) (io.ReadCloser, error) {
+	request := objectstorage.GetObjectRequest{
+		NamespaceName: &s.namespace,
+		BucketName:    &s.bucket,
+		ObjectName:    &name,
+	}
+
+	response, err := s.client.GetObject(ctx, request)
+	if err != nil {
+		return nil, ociError(err)
+	}
+	return response.Content, nil
+}
+
+func (s *OCIStore) Write(ctx context.Context, name string, r io.Reader) error {
+	// PutObject needs the content length up front, so buffer the body
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	size := int64(len(data))
+
+	request := objectstorage.PutObjectRequest{
+		NamespaceName: &s.namespace,
+		BucketName:    &s.bucket,
+		ObjectName:    &name,
+		ContentLength: &size,
+		PutObjectBody: io.NopCloser(bytes.NewReader(data)),
+	}
+	_, err = s.client.PutObject(ctx, request)
+	return err
+}
+
+func (s *OCIStore) Delete(ctx context.Context, name string) error {
+	request := objectstorage.DeleteObjectRequest{
+		NamespaceName: &s.namespace,
+		BucketName:    &s.bucket,
+		ObjectName:    &name,
+	}
+
+	_, err := s.client.DeleteObject(ctx, request)
+	return ociError(err)
+}
+
+// limit returns the Limit to send with ListObjects, or nil for the service default.
+func (s *OCIStore) limit() *int {
+	if s.PageSize <= 0 {
+		return nil
+	}
+	limit := s.PageSize
+	return &limit
+}
+
+// nextStart returns the Start for the next ListObjects call, or nil once the
+// listing is exhausted. A page that does not move the cursor forward would
+// loop forever, so it is reported as an error instead.
+func nextStart(start, nextStartWith *string) (*string, error) {
+	if nextStartWith == nil {
+		return nil, nil
+	}
+	if start != nil && *nextStartWith <= *start {
+		return nil, fmt.Errorf("listing did not advance past '%s'", *start)
+	}
+	return nextStartWith, nil
+}
+
+func ociAttrs(obj objectstorage.ObjectSummary) *ObjectAttrs {
+	attrs := &ObjectAttrs{Name: *obj.Name}
+	if obj.Size != nil {
+		attrs.Size = *obj.Size
+	}
+	if obj.TimeModified != nil {
+		attrs.Updated = obj.TimeModified.Time
+	} else if obj.TimeCreated != nil {
+		attrs.Updated = obj.TimeCreated.Time
+	}
+	if obj.Md5 != nil {
+		attrs.Checksums = ociChecksums(*obj.Md5)
+	}
+	return attrs
+}
+
+// ociChecksums files the md5 of a listing, which holds the multipart MD5
+// ("<md5>-<no. of parts>") for objects uploaded in parts.
+func ociChecksums(md5 string) Checksums {
+	if strings.Contains(md5, "-") {
+		return Checksums{MultipartMD5: md5}
+	}
+	return Checksums{MD5: md5}
+}
+
+// ociError maps a 404 from the service onto ErrObjectNotExist.
+func ociError(err error) error {
+	if serviceErr, ok := common.IsServiceError(err); ok && serviceErr.GetHTTPStatusCode() == http.StatusNotFound {
+		return ErrObjectNotExist
+	}
+	return err
+}
+
+// IsRetryableOCI reports whether an OCI call failed with a transient error: throttling
+// (HTTP 429), a 5xx or a network error.
+func IsRetryableOCI(err error) bool {
+	var serviceErr common.ServiceError
+	if errors.As(err, &serviceErr) {
+		return retry.IsRetryableStatus(serviceErr.GetHTTPStatusCode())
+	}
+	return common.IsNetworkError(err)
+}
@@ -0,0 +1,182 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+	"google.golang.org/api/option"
+
+	"common/retry"
+)
+
+const (
+	ProviderGCS = "gcs"
+	ProviderOCI = "oci"
+	ProviderS3  = "s3"
+	ProviderFS  = "fs"
+)
+
+// Config describes how to connect to a single bucket. Each side of a
+// comparison gets its own Config, so the two buckets can live in different
+// providers and use different credentials.
+type Config struct {
+	Provider string // ProviderGCS, ProviderOCI, ProviderS3 or ProviderFS
+	Bucket   string // for ProviderFS, the local directory the bucket was dumped to
+
+	// GCS: service account key file; empty uses application default credentials.
+	// Endpoint (below) points the client at another JSON API endpoint; a bare
+	// host:port gets https://, or http:// for an emulator. Emulator (or setting
+	// STORAGE_EMULATOR_HOST) talks to an emulator such as fake-gcs-server without
+	// credentials; STORAGE_EMULATOR_HOST is also the endpoint when Endpoint is empty.
+	CredentialsFile string
+	Emulator        bool
+
+	// OCI: object storage namespace, config file and profile; empty uses ~/.oci/config and DEFAULT
+	Namespace  string
+	ConfigFile string
+	Profile    string
+	PageSize   int // objects per ListObjects call (OCI and S3); 0 uses the service default
+
+	// S3: endpoint (e.g. "s3.amazonaws.com" or "localhost:9000" for a local MinIO),
+	// static credentials (empty reads AWS_* / MINIO_* env vars), region and addressing
+	Endpoint  string // GCS and S3
+	AccessKey string
+	SecretKey string
+	Region    string
+	PathStyle bool // path-style (endpoint/bucket/key) instead of virtual-hosted addressing
+	Insecure  bool // plain HTTP, e.g. for a local MinIO
+
+	// Retry, if set, retries listing and stat calls that fail with a transient
+	// error of the provider (see WithRetry) and counts them
+	Retry *retry.Metrics
+}
+
+// Open connects to the bucket described by cfg. The returned func releases
+// the underlying client and must be called once the store is no longer used.
+func Open(ctx context.Context, cfg Config) (Store, func(), error) {
+	store, closeStore, err := open(ctx, cfg)
+	if err != nil || cfg.Retry == nil {
+		return store, closeStore, err
+	}
+	if retryable := retryableFor(cfg.Provider); retryable != nil {
+		store = WithRetry(store, retryable, cfg.Retry)
+	}
+	return store, closeStore, nil
+}
+
+// retryableFor returns the classification of transient errors of provider, nil
+// for the local filesystem.
+func retryableFor(provider string) retry.Retryable {
+	switch provider {
+	case ProviderGCS:
+		return IsRetryableGCS
+	case ProviderOCI:
+		return IsRetryableOCI
+	case ProviderS3:
+		return IsRetryableS3
+	}
+	return nil
+}
+
+func open(ctx context.Context, cfg Config) (Store, func(), error) {
+	switch cfg.Provider {
+	case ProviderGCS:
+		client, err := NewGCSClient(ctx, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return NewGCSStore(client, cfg.Bucket), func() { client.Close() }, nil
+
+	case ProviderOCI:
+		provider := common.DefaultConfigProvider() // Reads configuration from ~/.oci/config
+		if cfg.ConfigFile != "" || cfg.Profile != "" {
+			profile := cfg.Profile
+			if profile == "" {
+				profile = "DEFAULT"
+			}
+			provider = common.CustomProfileConfigProvider(cfg.ConfigFile, profile)
+		}
+		client, err := objectstorage.NewObjectStorageClientWithConfigurationProvider(provider)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create object storage client: %w", err)
+		}
+		store := NewOCIStore(client, cfg.Namespace, cfg.Bucket)
+		store.PageSize = cfg.PageSize
+		return store, func() {}, nil
+
+	case ProviderS3:
+		creds := credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, "")
+		if cfg.AccessKey == "" {
+			creds = credentials.NewChainCredentials([]credentials.Provider{&credentials.EnvAWS{}, &credentials.EnvMinio{}})
+		}
+		lookup := minio.BucketLookupAuto
+		if cfg.PathStyle {
+			lookup = minio.BucketLookupPath
+		}
+		client, err := minio.New(cfg.Endpoint, &minio.Options{
+			Creds:        creds,
+			Secure:       !cfg.Insecure,
+			Region:       cfg.Region,
+			BucketLookup: lookup,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create s3 client: %w", err)
+		}
+		store := NewS3Store(client, cfg.Bucket)
+		store.PageSize = cfg.PageSize
+		return store, func() {}, nil
+
+	case ProviderFS:
+		return NewFSStore(cfg.Bucket), func() {}, nil
+	}
+	return nil, nil, fmt.Errorf("unknown provider %q", cfg.Provider)
+}
+
+// NewGCSClient creates a GCS client from the credentials and endpoint in cfg,
+// so several buckets can share one client. The caller must close it.
+func NewGCSClient(ctx context.Context, cfg Config) (*storage.Client, error) {
+	var opts []option.ClientOption
+	if cfg.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	}
+	emulator := cfg.Emulator || os.Getenv("STORAGE_EMULATOR_HOST") != ""
+	if cfg.Emulator && cfg.Endpoint == "" && os.Getenv("STORAGE_EMULATOR_HOST") == "" {
+		// Without an endpoint the client would reach the real GCS
+		return nil, errors.New("emulator requires an endpoint or STORAGE_EMULATOR_HOST")
+	}
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(gcsEndpoint(cfg.Endpoint, emulator)))
+		if emulator && cfg.CredentialsFile == "" {
+			// Emulators do not accept credentials
+			opts = append(opts, option.WithoutAuthentication())
+		}
+	}
+	// With no endpoint, storage.NewClient picks up STORAGE_EMULATOR_HOST itself
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %w", err)
+	}
+	return client, nil
+}
+
+// gcsEndpoint expands a bare host:port, as used in STORAGE_EMULATOR_HOST, into
+// the JSON API endpoint URL the client expects: over plain HTTP for an emulator,
+// else HTTPS. An endpoint with a scheme is used as is.
+func gcsEndpoint(endpoint string, emulator bool) string {
+	if strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+	scheme := "https://"
+	if emulator {
+		scheme = "http://"
+	}
+	return scheme + endpoint + "/storage/v1/"
+}
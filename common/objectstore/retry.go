@@ -0,0 +1,155 @@
+package objectstore
+
+import (
+	"context"
+
+	"common/retry"
+)
+
+// RetryStore retries the listing and stat calls of a Store that fail with an error
+// its Retryable accepts, see retry.Call. Reads, writes and deletes are passed through.
+type RetryStore struct {
+	Store
+	retryable retry.Retryable
+	metrics   *retry.Metrics
+}
+
+// WithRetry wraps store so its listing and stat calls are retried; metrics (may be nil)
+// counts the retries.
+func WithRetry(store Store, retryable retry.Retryable, metrics *retry.Metrics) *RetryStore {
+	return &RetryStore{Store: store, retryable: retryable, metrics: metrics}
+}
+
+// List retries each request of a PageLister, resuming after the last object
+// fetched. Other stores are listed again after the last object returned when
+// their listing fails half-way, see retryIterator.
+func (s *RetryStore) List(ctx context.Context, prefix string) ObjectIterator {
+	if lister, ok := s.Store.(PageLister); ok {
+		return &pageIterator{ctx: ctx, lister: retryPager{store: s, lister: lister}, prefix: prefix}
+	}
+	return &retryIterator{ctx: ctx, store: s, prefix: prefix}
+}
+
+func (s *RetryStore) ListPrefixes(ctx context.Context, prefix, delimiter string) ([]string, error) {
+	var prefixes []string
+	err := retry.Call(ctx, s.retryable, s.metrics, func() error {
+		var err error
+		prefixes, err = s.Store.ListPrefixes(ctx, prefix, delimiter)
+		return err
+	})
+	return prefixes, err
+}
+
+func (s *RetryStore) Stat(ctx context.Context, name string) (*ObjectAttrs, error) {
+	var attrs *ObjectAttrs
+	err := retry.Call(ctx, s.retryable, s.metrics, func() error {
+		var err error
+		attrs, err = s.Store.Stat(ctx, name)
+		return err
+	})
+	return attrs, err
+}
+
+// retryPager retries the page requests of a PageLister, each one is a call in the metrics.
+type retryPager struct {
+	store  *RetryStore
+	lister PageLister
+}
+
+func (p retryPager) ListPage(ctx context.Context, prefix, startAfter string) ([]*ObjectAttrs, bool, error) {
+	var page []*ObjectAttrs
+	var more bool
+	err := retry.Call(ctx, p.store.retryable, p.store.metrics, func() error {
+		var err error
+		page, more, err = p.lister.ListPage(ctx, prefix, startAfter)
+		return err
+	})
+	return page, more, err
+}
+
+// retryIterator retries a listing the store does not split into requests, e.g.
+// S3 where the client pages in the background: when it fails half-way, it starts
+// again after the last object returned (see AfterLister). Each start is a call in
+// the metrics, the failure that broke off the listing being its first attempt.
+type retryIterator struct {
+	ctx    context.Context
+	store  *RetryStore
+	prefix string
+	it     ObjectIterator // nil before the listing starts and after a failure
+	last   string         // name of the last object returned
+}
+
+func (i *retryIterator) Next() (*ObjectAttrs, error) {
+	if i.it == nil {
+		return i.start(nil)
+	}
+	attrs, err := i.it.Next()
+	if err == Done {
+		return nil, Done
+	}
+	if err != nil {
+		return i.start(err)
+	}
+	i.last = attrs.Name
+	return attrs, nil
+}
+
+func (i *retryIterator) Stop() {
+	if i.it != nil {
+		i.it.Stop()
+		i.it = nil
+	}
+}
+
+// start lists the objects after i.last and returns the first one. failed is the
+// error that broke off the listing, if any.
+func (i *retryIterator) start(failed error) (*ObjectAttrs, error) {
+	var attrs *ObjectAttrs
+	err := retry.Call(i.ctx, i.store.retryable, i.store.metrics, func() error {
+		i.Stop()
+		if err := failed; err != nil {
+			failed = nil
+			return err
+		}
+		i.it = i.store.listAfter(i.ctx, i.prefix, i.last)
+		var err error
+		if attrs, err = i.it.Next(); err == Done {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		i.Stop()
+		return nil, err
+	}
+	if attrs == nil {
+		return nil, Done
+	}
+	i.last = attrs.Name
+	return attrs, nil
+}
+
+// listAfter lists the objects under prefix after startAfter, skipping the others
+// when the store cannot start there.
+func (s *RetryStore) listAfter(ctx context.Context, prefix, startAfter string) ObjectIterator {
+	if lister, ok := s.Store.(AfterLister); ok {
+		return lister.ListAfter(ctx, prefix, startAfter)
+	}
+	return &skipIterator{ObjectIterator: s.Store.List(ctx, prefix), after: startAfter}
+}
+
+// skipIterator drops the objects up to and including after, which works because
+// List returns them in order of name.
+type skipIterator struct {
+	ObjectIterator
+	after string
+}
+
+func (i *skipIterator) Next() (*ObjectAttrs, error) {
+	for {
+		attrs, err := i.ObjectIterator.Next()
+		if err != nil || attrs.Name > i.after {
+			return attrs, err
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"common/retry"
+)
+
+var errTransient = errors.New("503 service unavailable")
+
+func isTransient(err error) bool {
+	return errors.Is(err, errTransient)
+}
+
+func TestRetryStoreList(t *testing.T) {
+	m := newTestStore()
+	m.PageSize = 1
+	var metrics retry.Metrics
+	s := WithRetry(m, isTransient, &metrics)
+
+	it := s.List(context.Background(), "root/")
+	var names []string
+	for {
+		attrs, err := it.Next()
+		if err == Done {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		names = append(names, attrs.Name)
+		if len(names) == 2 {
+			// The next page fails once, the listing resumes after root/a/2.ts
+			m.InjectFault("root/", Fault{Err: errTransient, Times: 1})
+		}
+	}
+
+	want := []string{"root/a/1.ts", "root/a/2.ts", "root/b/1.ts", "root/b/seg/2.ts", "root/c.txt"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+	// One call per page, only the page that failed is requested again
+	if stats := metrics.Stats(); stats.Calls != 5 || stats.Retried != 1 || stats.Retries != 1 || stats.GaveUp != 0 {
+		t.Errorf("metrics = %+v, want 5 calls, 1 retried once", stats)
+	}
+	if got := m.ListCalls(); got != 5 {
+		t.Errorf("ListCalls() = %d, want 5", got)
+	}
+}
+
+// streamStore hides the pages of a Store, like S3 where the client pages in the background.
+type streamStore struct {
+	Store
+}
+
+func TestRetryStoreListStream(t *testing.T) {
+	m := newTestStore()
+	m.PageSize = 1
+	var metrics retry.Metrics
+	it := WithRetry(streamStore{m}, isTransient, &metrics).List(context.Background(), "root/")
+	defer it.Stop()
+
+	var names []string
+	for {
+		attrs, err := it.Next()
+		if err == Done {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		names = append(names, attrs.Name)
+		if len(names) == 2 {
+			m.InjectFault("root/", Fault{Err: errTransient, Times: 1})
+		}
+	}
+
+	want := []string{"root/a/1.ts", "root/a/2.ts", "root/b/1.ts", "root/b/seg/2.ts", "root/c.txt"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+	// The listing is started once and resumed once, the failed read being the first attempt
+	if stats := metrics.Stats(); stats.Calls != 2 || stats.Retried != 1 || stats.Retries != 1 {
+		t.Errorf("metrics = %+v, want 2 calls, 1 retried once", stats)
+	}
+}
+
+func TestRetryStoreStat(t *testing.T) {
+	errPermanent := errors.New("403 forbidden")
+	tests := []struct {
+		name        string
+		fault       Fault
+		wantErr     error
+		wantRetries int
+	}{
+		{name: "no fault"},
+		{name: "transient", fault: Fault{Err: errTransient, Times: 2}, wantRetries: 2},
+		{name: "not retryable", fault: Fault{Err: errPermanent}, wantErr: errPermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestStore()
+			if tt.fault.Err != nil {
+				m.InjectFault("root/a/", tt.fault)
+			}
+			var metrics retry.Metrics
+			attrs, err := WithRetry(m, isTransient, &metrics).Stat(context.Background(), "root/a/1.ts")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Stat() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && attrs.Name != "root/a/1.ts" {
+				t.Errorf("Stat() = %+v", attrs)
+			}
+			if stats := metrics.Stats(); stats.Calls != 1 || stats.Retries != tt.wantRetries {
+				t.Errorf("metrics = %+v, want 1 call with %d retries", stats, tt.wantRetries)
+			}
+		})
+	}
+}
+
+// ociServiceError is a common.ServiceError with just a status code.
+type ociServiceError int
+
+func (e ociServiceError) GetHTTPStatusCode() int  { return int(e) }
+func (e ociServiceError) GetMessage() string      { return "" }
+func (e ociServiceError) GetCode() string         { return "" }
+func (e ociServiceError) GetOpcRequestID() string { return "" }
+func (e ociServiceError) Error() string           { return fmt.Sprintf("status %d", int(e)) }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name      string
+		retryable retry.Retryable
+		err       error
+		want      bool
+	}{
+		{name: "gcs 503", retryable: IsRetryableGCS, err: &googleapi.Error{Code: 503}, want: true},
+		{name: "gcs 429 wrapped", retryable: IsRetryableGCS, err: fmt.Errorf("list: %w", &googleapi.Error{Code: 429}), want: true},
+		{name: "gcs 404", retryable: IsRetryableGCS, err: &googleapi.Error{Code: 404}},
+		{name: "gcs grpc unavailable", retryable: IsRetryableGCS, err: status.Error(codes.Unavailable, "down"), want: true},
+		{name: "gcs grpc resource exhausted", retryable: IsRetryableGCS, err: status.Error(codes.ResourceExhausted, "quota"), want: true},
+		{name: "gcs grpc not found", retryable: IsRetryableGCS, err: status.Error(codes.NotFound, "gone")},
+		{name: "gcs missing object", retryable: IsRetryableGCS, err: ErrObjectNotExist},
+		{name: "oci throttled", retryable: IsRetryableOCI, err: fmt.Errorf("failed to list objects: %w", ociServiceError(429)), want: true},
+		{name: "oci 500", retryable: IsRetryableOCI, err: ociServiceError(500), want: true},
+		{name: "oci 401", retryable: IsRetryableOCI, err: ociServiceError(401)},
+		{name: "s3 slow down", retryable: IsRetryableS3, err: minio.ErrorResponse{Code: "SlowDown", StatusCode: 503}, want: true},
+		{name: "s3 502", retryable: IsRetryableS3, err: minio.ErrorResponse{StatusCode: 502}, want: true},
+		{name: "s3 access denied", retryable: IsRetryableS3, err: minio.ErrorResponse{Code: "AccessDenied", StatusCode: 403}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.retryable(tt.err); got != tt.want {
+				t.Errorf("retryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+
+	"common/retry"
+)
+
+// S3Store is a Store backed by a bucket in S3-compatible storage (AWS S3, MinIO, ...).
+type S3Store struct {
+	client *minio.Client
+	bucket string
+
+	// PageSize is the max no. of objects per ListObjects call; 0 uses the service default (1000).
+	PageSize int
+}
+
+// NewS3Store returns a Store for bucket using an existing S3 client.
+func NewS3Store(client *minio.Client, bucket string) *S3Store {
+	return &S3Store{client: client, bucket: bucket}
+}
+
+func (s *S3Store) Name() string {
+	return s.bucket
+}
+
+func (s *S3Store) List(ctx context.Context, prefix string) ObjectIterator {
+	return s.ListAfter(ctx, prefix, "")
+}
+
+// ListAfter lists the objects after startAfter (S3 StartAfter), see AfterLister.
+// The client pages in the background, so a listing cannot be split into requests.
+func (s *S3Store) ListAfter(ctx context.Context, prefix, startAfter string) ObjectIterator {
+	// Cancelled once the listing is drained or stopped so the client's goroutine can exit
+	ctx, cancel := context.WithCancel(ctx)
+	opts := minio.ListObjectsOptions{Prefix: prefix, Recursive: true, MaxKeys: s.PageSize, StartAfter: startAfter}
+	return &s3Iterator{ch: s.client.ListObjects(ctx, s.bucket, opts), cancel: cancel}
+}
+
+func (s *S3Store) ListPrefixes(ctx context.Context, prefix, delimiter string) ([]string, error) {
+	// The client only lists non-recursively with "/" as the delimiter
+	if delimiter != "/" {
+		return nil, fmt.Errorf("unsupported delimiter %q, only \"/\" is supported", delimiter)
+	}
+	opts := minio.ListObjectsOptions{Prefix: prefix, MaxKeys: s.PageSize}
+	var prefixes []string
+
+	for obj := range s.client.ListObjects(ctx, s.bucket, opts) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", obj.Err)
+		}
+		// Common prefixes come back as keys ending in the delimiter, skip the folder marker itself
+		if strings.HasSuffix(obj.Key, delimiter) && obj.Key != prefix {
+			prefixes = append(prefixes, obj.Key)
+		}
+	}
+	return prefixes, nil
+}
+
+func (s *S3Store) Stat(ctx context.Context, name string) (*ObjectAttrs, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return s3Attrs(info), nil
+}
+
+func (s *S3Store) Read(ctx context.Context, name string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	// GetObject is lazy, Stat surfaces a missing object before the first Read
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, s3Error(err)
+	}
+	return obj, nil
+}
+
+func (s *S3Store) Write(ctx context.Context, name string, r io.Reader) error {
+	// A size of -1 streams the body as a multipart upload
+	_, err := s.client.PutObject(ctx, s.bucket, name, r, -1, minio.PutObjectOptions{})
+	return err
+}
+
+func (s *S3Store) Delete(ctx context.Context, name string) error {
+	// S3 deletes are idempotent, stat first to report missing objects like the other stores
+	if _, err := s.Stat(ctx, name); err != nil {
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{})
+}
+
+type s3Iterator struct {
+	ch     <-chan minio.ObjectInfo
+	cancel context.CancelFunc
+}
+
+func (i *s3Iterator) Next() (*ObjectAttrs, error) {
+	obj, ok := <-i.ch
+	if !ok {
+		i.cancel()
+		return nil, Done
+	}
+	if obj.Err != nil {
+		i.cancel()
+		return nil, fmt.Errorf("failed to list objects: %w", obj.Err)
+	}
+	return s3Attrs(obj), nil
+}
+
+func (i *s3Iterator) Stop() {
+	i.cancel()
+	// The client still sends the cancellation, then closes the channel
+	for range i.ch {
+	}
+}
+
+func s3Attrs(info minio.ObjectInfo) *ObjectAttrs {
+	return &ObjectAttrs{
+		Name:     info.Key,
+		Size:     info.Size,
+		Updated:  info.LastModified,
+		Metadata: info.UserMetadata,
+	}
+}
+
+// s3Error maps a NoSuchKey response onto ErrObjectNotExist.
+func s3Error(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrObjectNotExist
+	}
+	return err
+}
+
+// IsRetryableS3 reports whether an S3 call failed with a transient error: HTTP 429
+// (or a SlowDown reply) or a 5xx.
+func IsRetryableS3(err error) bool {
+	var resp minio.ErrorResponse
+	if !errors.As(err, &resp) {
+		return false
+	}
+	return resp.Code == "SlowDown" || retry.IsRetryableStatus(resp.StatusCode)
+}
@@ -0,0 +1,153 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// TestS3StoreMinIO runs against a local MinIO, e.g.
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	MINIO_ENDPOINT=localhost:9000 go test ./objectstore -run MinIO
+func TestS3StoreMinIO(t *testing.T) {
+	endpoint := os.Getenv("MINIO_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("MINIO_ENDPOINT not set")
+	}
+	accessKey, secretKey := os.Getenv("MINIO_ACCESS_KEY"), os.Getenv("MINIO_SECRET_KEY")
+	if accessKey == "" {
+		accessKey, secretKey = "minioadmin", "minioadmin"
+	}
+	bucket := fmt.Sprintf("objectstore-test-%d", time.Now().UnixNano())
+	ctx := context.Background()
+
+	store, closeStore, err := Open(ctx, Config{
+		Provider:  ProviderS3,
+		Bucket:    bucket,
+		Endpoint:  endpoint,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		PathStyle: true,
+		Insecure:  true,
+		PageSize:  2,
+	})
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer closeStore()
+
+	client := store.(*S3Store).client
+	if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+		t.Fatalf("MakeBucket() error = %v", err)
+	}
+	names := []string{"root/a/1.ts", "root/a/2.ts", "root/a/3.ts", "root/b/1.ts", "root/c.txt"}
+	defer func() {
+		for _, name := range names {
+			client.RemoveObject(ctx, bucket, name, minio.RemoveObjectOptions{})
+		}
+		client.RemoveBucket(ctx, bucket)
+	}()
+	for _, name := range names {
+		if err := store.Write(ctx, name, strings.NewReader(name)); err != nil {
+			t.Fatalf("Write(%q) error = %v", name, err)
+		}
+	}
+
+	if got, err := CountFiles(ctx, store, "root/a/"); err != nil || got != 3 {
+		t.Errorf("CountFiles(root/a/) = %d, %v, want 3", got, err)
+	}
+	prefixes, err := store.ListPrefixes(ctx, "root/", "/")
+	if err != nil {
+		t.Fatalf("ListPrefixes() error = %v", err)
+	}
+	if want := []string{"root/a/", "root/b/"}; !reflect.DeepEqual(prefixes, want) {
+		t.Errorf("ListPrefixes() = %v, want %v", prefixes, want)
+	}
+	attrs, err := store.Stat(ctx, "root/c.txt")
+	if err != nil || attrs.Size != int64(len("root/c.txt")) {
+		t.Errorf("Stat() = %+v, %v", attrs, err)
+	}
+	if _, err := store.Stat(ctx, "root/missing"); !errors.Is(err, ErrObjectNotExist) {
+		t.Errorf("Stat() on missing object error = %v, want %v", err, ErrObjectNotExist)
+	}
+}
+
+func TestS3ListAfter(t *testing.T) {
+	var startAfter string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		startAfter = r.URL.Query().Get("start-after")
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>bucket</Name><Prefix>root/</Prefix><KeyCount>1</KeyCount><MaxKeys>1000</MaxKeys><IsTruncated>false</IsTruncated>
+<Contents><Key>root/2.ts</Key><Size>1</Size></Contents></ListBucketResult>`)
+	}))
+	defer server.Close()
+	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds:        credentials.NewStaticV4("key", "secret", ""),
+		Region:       "us-east-1",
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	it := NewS3Store(client, "bucket").ListAfter(context.Background(), "root/", "root/1.ts")
+	defer it.Stop()
+	if attrs, err := it.Next(); err != nil || attrs.Name != "root/2.ts" {
+		t.Fatalf("Next() = %+v, %v, want root/2.ts", attrs, err)
+	}
+	if startAfter != "root/1.ts" {
+		t.Errorf("start-after = %q, want root/1.ts", startAfter)
+	}
+}
+
+func TestS3IteratorStop(t *testing.T) {
+	// Every page holds 2 objects and says there are more
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>bucket</Name><Prefix>root/</Prefix><KeyCount>2</KeyCount><MaxKeys>2</MaxKeys><IsTruncated>true</IsTruncated><NextContinuationToken>next</NextContinuationToken>
+<Contents><Key>root/1.ts</Key><Size>1</Size></Contents><Contents><Key>root/2.ts</Key><Size>1</Size></Contents></ListBucketResult>`)
+	}))
+	defer server.Close()
+	client, err := minio.New(strings.TrimPrefix(server.URL, "http://"), &minio.Options{
+		Creds:        credentials.NewStaticV4("key", "secret", ""),
+		Region:       "us-east-1",
+		BucketLookup: minio.BucketLookupPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	it := NewS3Store(client, "bucket").List(context.Background(), "root/")
+	if _, err := it.Next(); err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	it.Stop()
+
+	// The client's listing goroutine exits instead of blocking on the next object
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		stacks := make([]byte, 1<<20)
+		stacks = stacks[:runtime.Stack(stacks, true)]
+		if !bytes.Contains(stacks, []byte("minio-go/v7.(*Client).listObjectsV2")) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("listing goroutine still running after Stop:\n%s", stacks)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
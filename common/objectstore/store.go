@@ -0,0 +1,155 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Done is returned by ObjectIterator.Next when there are no more objects.
+var Done = errors.New("no more items in iterator")
+
+// ErrObjectNotExist is returned by Stat and Read when the object is missing.
+var ErrObjectNotExist = errors.New("object does not exist")
+
+// ObjectAttrs is the provider-neutral subset of an object's metadata.
+type ObjectAttrs struct {
+	Name      string
+	Size      int64
+	Updated   time.Time
+	Metadata  map[string]string // custom metadata; nil if the listing leaves it out (OCI, S3), Stat returns it
+	Checksums Checksums         // as stored by the provider, see HashObject for computing them
+}
+
+// ObjectIterator iterates over the objects returned by Store.List.
+type ObjectIterator interface {
+	// Next returns the next object, or Done once the listing is exhausted.
+	Next() (*ObjectAttrs, error)
+	// Stop releases the listing, e.g. a request still fetching in the background.
+	// Next must not be called after Stop; callers that may not drain the listing
+	// must call it.
+	Stop()
+}
+
+// Store is a single bucket in an object storage provider (GCS, OCI, ...).
+type Store interface {
+	// Name identifies the bucket in logs and reports.
+	Name() string
+	// List returns every object whose name starts with prefix, in lexicographic order of name.
+	List(ctx context.Context, prefix string) ObjectIterator
+	// ListPrefixes returns the common prefixes directly under prefix,
+	// e.g. "root/a/" and "root/b/" for prefix "root/" and delimiter "/".
+	ListPrefixes(ctx context.Context, prefix, delimiter string) ([]string, error)
+	// Stat returns the attributes of a single object.
+	Stat(ctx context.Context, name string) (*ObjectAttrs, error)
+	// Read opens an object for reading. The caller must close the reader.
+	Read(ctx context.Context, name string) (io.ReadCloser, error)
+	// Write creates or overwrites an object with the contents of r.
+	Write(ctx context.Context, name string, r io.Reader) error
+	// Delete removes an object.
+	Delete(ctx context.Context, name string) error
+}
+
+// PageLister is a Store that lists one page per request, so a listing can be
+// retried a page at a time (see RetryStore).
+type PageLister interface {
+	// ListPage returns the objects under prefix whose name comes after startAfter
+	// ("" for the first page), in lexicographic order of name, from a single
+	// request, and whether more follow.
+	ListPage(ctx context.Context, prefix, startAfter string) ([]*ObjectAttrs, bool, error)
+}
+
+// AfterLister is a Store whose listing can start after a given name, so a listing
+// that failed half-way can resume where it stopped (see RetryStore).
+type AfterLister interface {
+	// ListAfter is List without the names up to and including startAfter.
+	ListAfter(ctx context.Context, prefix, startAfter string) ObjectIterator
+}
+
+// pageIterator returns the objects of a PageLister one page at a time.
+type pageIterator struct {
+	ctx     context.Context
+	lister  PageLister
+	prefix  string
+	objects []*ObjectAttrs
+	last    string // name of the last object fetched
+	done    bool
+}
+
+func (i *pageIterator) Next() (*ObjectAttrs, error) {
+	for len(i.objects) == 0 {
+		if i.done {
+			return nil, Done
+		}
+		page, more, err := i.lister.ListPage(i.ctx, i.prefix, i.last)
+		if err != nil {
+			return nil, err
+		}
+		// An empty page that says there are more would loop forever
+		if len(page) == 0 && more {
+			return nil, fmt.Errorf("listing did not advance past '%s'", i.last)
+		}
+		i.objects, i.done = page, !more
+		if len(page) > 0 {
+			i.last = page[len(page)-1].Name
+		}
+	}
+	obj := i.objects[0]
+	i.objects = i.objects[1:]
+	return obj, nil
+}
+
+func (i *pageIterator) Stop() {}
+
+// Stats summarizes the objects at a prefix.
+type Stats struct {
+	Count     int   // no. of objects
+	Bytes     int64 // total size
+	MinSize   int64 // size of the smallest object, 0 if there are none
+	MaxSize   int64 // size of the largest object
+	ZeroBytes int   // no. of empty objects, e.g. truncated uploads
+}
+
+// Add counts an object of the given size.
+func (s *Stats) Add(size int64) {
+	if s.Count == 0 || size < s.MinSize {
+		s.MinSize = size
+	}
+	if size > s.MaxSize {
+		s.MaxSize = size
+	}
+	if size == 0 {
+		s.ZeroBytes++
+	}
+	s.Count++
+	s.Bytes += size
+}
+
+// PrefixStats returns the Stats of the objects at a specific prefix (path) in the store.
+func PrefixStats(ctx context.Context, store Store, prefix string) (Stats, error) {
+	it := store.List(ctx, prefix)
+	defer it.Stop()
+	var stats Stats
+
+	// Iterate over objects and add them up
+	for {
+		attrs, err := it.Next()
+		if err == Done {
+			// No more objects to iterate
+			break
+		}
+		if err != nil {
+			return Stats{}, err
+		}
+		stats.Add(attrs.Size)
+	}
+	return stats, nil
+}
+
+// CountFiles returns the no. of objects at a specific prefix (path) in the store.
+func CountFiles(ctx context.Context, store Store, prefix string) (int, error) {
+	stats, err := PrefixStats(ctx, store, prefix)
+	return stats.Count, err
+}
@@ -0,0 +1,93 @@
+// Package retry retries the calls to the storage providers and Spanner that fail
+// with a transient error, using the backoff of utils.RetryState.
+package retry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"utils"
+)
+
+// Retryable reports whether a call that failed with err is worth retrying.
+type Retryable func(err error) bool
+
+// Stats are the retries of many calls, added up from their utils.RetryState.
+type Stats struct {
+	Calls   int           // calls made, e.g. one per list page request (per start of an S3 listing)
+	Retried int           // calls that took more than one attempt
+	Retries int           // attempts after the first
+	GaveUp  int           // calls that still failed with a retryable error
+	Backoff time.Duration // time spent in the calls that were retried
+}
+
+// Metrics collects the Stats of the calls made with it; it is safe for concurrent use.
+type Metrics struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Stats returns the Stats so far.
+func (m *Metrics) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stats
+}
+
+// add counts a call tracked by state.
+func (m *Metrics) add(state *utils.RetryState, gaveUp bool) {
+	if m == nil {
+		return
+	}
+	attempts := state.Attempt // backoffs taken, i.e. attempts after the first
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats.Calls++
+	if attempts > 0 {
+		m.stats.Retried++
+		m.stats.Retries += attempts
+		m.stats.Backoff += state.Elapsed()
+	}
+	if gaveUp {
+		m.stats.GaveUp++
+	}
+}
+
+// String summarizes the stats, e.g. "3 of 1200 call(s) retried (5 retries, 1 gave up, 1.2s)".
+func (s Stats) String() string {
+	return fmt.Sprintf("%d of %d call(s) retried (%d retries, %d gave up, %v)", s.Retried, s.Calls, s.Retries, s.GaveUp, s.Backoff.Round(time.Millisecond))
+}
+
+// Call runs fn until it succeeds, fails with an error retryable rejects, or the
+// attempts or time of a utils.RetryState run out (see RetryState.Policy); it
+// returns the last error. The call is counted in metrics, which may be nil.
+func Call(ctx context.Context, retryable Retryable, metrics *Metrics, fn func() error) error {
+	state := utils.NewRetryState()
+	err := utils.Do(ctx, state.Policy(retryable), func(ctx context.Context) error {
+		return fn()
+	})
+	metrics.add(state, err != nil && retryable(err) && ctx.Err() == nil)
+	return err
+}
+
+// IsRetryableStatus reports whether an HTTP status is transient: 429 Too Many Requests or a 5xx.
+func IsRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// IsRetryableGRPC reports whether err is a gRPC status for a transient failure:
+// Unavailable or ResourceExhausted.
+func IsRetryableGRPC(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+	return s.Code() == codes.Unavailable || s.Code() == codes.ResourceExhausted
+}
@@ -0,0 +1,62 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCall(t *testing.T) {
+	errTransient := errors.New("unavailable")
+	errPermanent := errors.New("forbidden")
+	isTransient := func(err error) bool { return errors.Is(err, errTransient) }
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		errs        []error // returned by the attempts in turn, then nil
+		wantErr     error
+		wantAttempt int
+		wantStats   Stats
+	}{
+		{name: "success", ctx: context.Background(), wantAttempt: 1, wantStats: Stats{Calls: 1}},
+		{name: "transient", ctx: context.Background(), errs: []error{errTransient}, wantAttempt: 2, wantStats: Stats{Calls: 1, Retried: 1, Retries: 1}},
+		{name: "not retryable", ctx: context.Background(), errs: []error{errPermanent}, wantErr: errPermanent, wantAttempt: 1, wantStats: Stats{Calls: 1}},
+		{name: "canceled", ctx: canceled, errs: []error{errTransient}, wantErr: errTransient, wantAttempt: 1, wantStats: Stats{Calls: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var metrics Metrics
+			attempt := 0
+			err := Call(tt.ctx, isTransient, &metrics, func() error {
+				attempt++
+				if attempt <= len(tt.errs) {
+					return tt.errs[attempt-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Call() error = %v, want %v", err, tt.wantErr)
+			}
+			if attempt != tt.wantAttempt {
+				t.Errorf("attempts = %d, want %d", attempt, tt.wantAttempt)
+			}
+			stats := metrics.Stats()
+			stats.Backoff = 0
+			if stats != tt.wantStats {
+				t.Errorf("Stats() = %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestStatsString(t *testing.T) {
+	s := Stats{Calls: 1200, Retried: 3, Retries: 5, GaveUp: 1, Backoff: 1234567 * time.Microsecond}
+	if got, want := s.String(), "3 of 1200 call(s) retried (5 retries, 1 gave up, 1.235s)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
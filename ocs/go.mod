@@ -1,11 +0,0 @@
-module ocs_path
-
-go 1.23.1
-
-require github.com/oracle/oci-go-sdk/v65 v65.79.0
-
-require (
-	github.com/gofrs/flock v0.8.1 // indirect
-	github.com/sony/gobreaker v0.5.0 // indirect
-	golang.org/x/sys v0.8.0 // indirect
-)
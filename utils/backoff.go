@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff computes the wait before each retry of a Policy.
+type Backoff interface {
+	// Next returns the wait before retry n (1 for the first); prev is the wait
+	// before retry n-1, 0 for the first.
+	Next(n int, prev time.Duration) time.Duration
+}
+
+// Exponential waits Initial * Multiplier^n before retry n, plus up to Jitter
+// (e.g. 0.2 for 20%) of that at random, and at most Max.
+type Exponential struct {
+	Initial    time.Duration // default InitialRetryInterval
+	Multiplier float64       // default 2
+	Jitter     float64
+	Max        time.Duration // 0 for no limit
+	Rand       Rand          // source of the jitter, default math/rand
+}
+
+func (b Exponential) Next(n int, prev time.Duration) time.Duration {
+	initial, multiplier := b.Initial, b.Multiplier
+	if initial <= 0 {
+		initial = InitialRetryInterval
+	}
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	wait := float64(initial) * math.Pow(multiplier, float64(n))
+	wait += randFloat64(b.Rand) * wait * b.Jitter
+	return capWait(wait, b.Max)
+}
+
+// DecorrelatedJitter waits a random time between Base and 3 times the previous
+// wait, at most Max, which spreads out callers that failed at the same time.
+type DecorrelatedJitter struct {
+	Base time.Duration // default InitialRetryInterval
+	Max  time.Duration // default MaxTotalTimeout
+	Rand Rand          // source of the waits, default math/rand
+}
+
+func (b DecorrelatedJitter) Next(n int, prev time.Duration) time.Duration {
+	base, limit := b.Base, b.Max
+	if base <= 0 {
+		base = InitialRetryInterval
+	}
+	if limit <= 0 {
+		limit = MaxTotalTimeout
+	}
+	if prev < base {
+		prev = base
+	}
+	wait := float64(base) + randFloat64(b.Rand)*float64(3*prev-base)
+	return capWait(wait, limit)
+}
+
+// Constant waits Interval before every retry.
+type Constant struct {
+	Interval time.Duration // default InitialRetryInterval
+}
+
+func (b Constant) Next(n int, prev time.Duration) time.Duration {
+	if b.Interval <= 0 {
+		return InitialRetryInterval
+	}
+	return b.Interval
+}
+
+// Fibonacci waits Initial times the n-th Fibonacci number (1, 1, 2, 3, 5, ...)
+// before retry n, at most Max; it grows slower than Exponential.
+type Fibonacci struct {
+	Initial time.Duration // default InitialRetryInterval
+	Max     time.Duration // 0 for no limit
+}
+
+func (b Fibonacci) Next(n int, prev time.Duration) time.Duration {
+	initial := b.Initial
+	if initial <= 0 {
+		initial = InitialRetryInterval
+	}
+	a, c := 0.0, 1.0
+	for i := 0; i < n; i++ {
+		a, c = c, a+c
+	}
+	return capWait(float64(initial)*a, b.Max)
+}
+
+// randFloat64 draws a number in [0, 1) from r, or from math/rand if r is nil.
+func randFloat64(r Rand) float64 {
+	if r == nil {
+		return rand.Float64()
+	}
+	return r.Float64()
+}
+
+// capWait converts wait to a Duration of at most limit (0 for no limit),
+// guarding against overflow after many retries.
+func capWait(wait float64, limit time.Duration) time.Duration {
+	if limit > 0 && wait > float64(limit) {
+		return limit
+	}
+	if wait >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(wait)
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Policy says how Do retries a call. The zero Policy retries every error like
+// RetryState: up to MaxTotalAttempts retries, backing off exponentially from
+// InitialRetryInterval with JitterFactor jitter, within MaxTotalTimeout.
+type Policy struct {
+	Backoff        Backoff            // wait before each retry, default Exponential with JitterFactor
+	MaxAttempts    int                // attempts including the first, default MaxTotalAttempts+1 (which caps retries)
+	Timeout        time.Duration      // no retry starts after this long and waits are cut to what is left, default MaxTotalTimeout
+	AttemptTimeout time.Duration      // deadline of each attempt, 0 for none
+	Retryable      func(error) bool   // whether an error is worth retrying, default every error
+	OnRetry        func(RetryAttempt) // called before each wait, e.g. to count retries
+	Clock          Clock              // tells the time for Timeout, default the system clock
+	Rand           Rand               // draws the jitter of the default Backoff, default math/rand
+}
+
+// RetryAttempt describes a failed attempt that is about to be retried.
+type RetryAttempt struct {
+	Attempt int           // the attempt that failed, 1 for the first
+	Err     error         // its error
+	Wait    time.Duration // wait before the next attempt
+	Elapsed time.Duration // time since the call started
+}
+
+func (p Policy) backoff() Backoff {
+	if p.Backoff == nil {
+		return Exponential{Jitter: JitterFactor, Rand: p.Rand}
+	}
+	return p.Backoff
+}
+
+func (p Policy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return MaxTotalAttempts + 1
+	}
+	return p.MaxAttempts
+}
+
+func (p Policy) timeout() time.Duration {
+	if p.Timeout <= 0 {
+		return MaxTotalTimeout
+	}
+	return p.Timeout
+}
+
+func (p Policy) clock() Clock {
+	if p.Clock == nil {
+		return systemClock{}
+	}
+	return p.Clock
+}
+
+func (p Policy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// Do calls fn until it succeeds or policy gives up, and returns its last error.
+// If ctx is done while waiting, the error is joined with ctx.Err().
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	_, err := DoValue(ctx, policy, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// DoValue is Do for a call that returns a value, the one of the successful attempt.
+func DoValue[T any](ctx context.Context, policy Policy, fn func(ctx context.Context) (T, error)) (T, error) {
+	clock := policy.clock()
+	start := clock.Now()
+	var wait time.Duration
+	for attempt := 1; ; attempt++ {
+		v, err := callAttempt(ctx, policy.AttemptTimeout, fn)
+		if err == nil || !policy.retryable(err) || attempt >= policy.maxAttempts() || ctx.Err() != nil {
+			return v, err
+		}
+
+		elapsed := clock.Now().Sub(start)
+		remaining := policy.timeout() - elapsed
+		if remaining <= 0 {
+			return v, err
+		}
+		wait = min(policy.backoff().Next(attempt, wait), remaining)
+		if policy.OnRetry != nil {
+			policy.OnRetry(RetryAttempt{Attempt: attempt, Err: err, Wait: wait, Elapsed: elapsed})
+		}
+		if sleepErr := sleep(ctx, wait); sleepErr != nil {
+			return v, errors.Join(err, sleepErr)
+		}
+	}
+}
+
+// callAttempt calls fn with a deadline of timeout, if set.
+func callAttempt[T any](ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+// sleep waits for d, or returns ctx.Err() as soon as ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBackoffs(t *testing.T) {
+	tests := []struct {
+		name    string
+		backoff Backoff
+		want    []time.Duration // waits before retries 1, 2, ...
+	}{
+		{name: "exponential", backoff: Exponential{}, want: []time.Duration{400 * time.Millisecond, 800 * time.Millisecond, 1600 * time.Millisecond}},
+		{name: "exponential capped", backoff: Exponential{Initial: time.Second, Multiplier: 3, Max: 5 * time.Second}, want: []time.Duration{3 * time.Second, 5 * time.Second, 5 * time.Second}},
+		{name: "constant", backoff: Constant{Interval: time.Second}, want: []time.Duration{time.Second, time.Second, time.Second}},
+		{name: "constant default", backoff: Constant{}, want: []time.Duration{InitialRetryInterval}},
+		{name: "fibonacci", backoff: Fibonacci{Initial: time.Second}, want: []time.Duration{1 * time.Second, 1 * time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second, 8 * time.Second}},
+		{name: "fibonacci capped", backoff: Fibonacci{Initial: time.Second, Max: 4 * time.Second}, want: []time.Duration{1 * time.Second, 1 * time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second}},
+		{name: "exponential with jitter", backoff: Exponential{Initial: time.Second, Jitter: 0.5, Rand: fixedRand(0.5)}, want: []time.Duration{2500 * time.Millisecond, 5 * time.Second, 10 * time.Second}},
+		{name: "decorrelated jitter", backoff: DecorrelatedJitter{Base: time.Second, Max: 5 * time.Second, Rand: fixedRand(0.5)}, want: []time.Duration{2 * time.Second, 3500 * time.Millisecond, 5 * time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prev time.Duration
+			for i, want := range tt.want {
+				got := tt.backoff.Next(i+1, prev)
+				if got != want {
+					t.Errorf("Next(%d) = %v, want %v", i+1, got, want)
+				}
+				prev = got
+			}
+		})
+	}
+}
+
+func TestBackoffsWithJitter(t *testing.T) {
+	var prev time.Duration
+	jitter := DecorrelatedJitter{Base: time.Second, Max: 20 * time.Second}
+	for n := 1; n <= 20; n++ {
+		got := jitter.Next(n, prev)
+		if got < time.Second || got > max(3*prev, time.Second*3) || got > 20*time.Second {
+			t.Fatalf("DecorrelatedJitter.Next(%d, %v) = %v, want between 1s and min(3*prev, 20s)", n, prev, got)
+		}
+		prev = got
+	}
+
+	exponential := Exponential{Initial: time.Second, Jitter: 0.5}
+	for n := 1; n <= 20; n++ {
+		if got := exponential.Next(1, 0); got < 2*time.Second || got > 3*time.Second {
+			t.Fatalf("Exponential.Next(1) = %v, want between 2s and 3s", got)
+		}
+	}
+}
+
+func TestPolicyRand(t *testing.T) {
+	// The default backoff draws its jitter from the Policy's Rand, RetryState's own for its Policy
+	policy := NewRetryStateWith(nil, fixedRand(0.5)).Policy(nil)
+	policy.Backoff = nil
+	for _, p := range []Policy{{Rand: fixedRand(0.5)}, policy} {
+		if got, want := p.backoff().Next(1, 0), 440*time.Millisecond; got != want {
+			t.Errorf("Next(1) = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDo(t *testing.T) {
+	errTransient := errors.New("unavailable")
+	errPermanent := errors.New("forbidden")
+	fast := Constant{Interval: time.Millisecond}
+
+	tests := []struct {
+		name        string
+		policy      Policy
+		errs        []error // returned by the attempts in turn, then nil
+		wantErr     error
+		wantAttempt int
+	}{
+		{name: "success", policy: Policy{Backoff: fast}, wantAttempt: 1},
+		{name: "transient", policy: Policy{Backoff: fast}, errs: []error{errTransient, errTransient}, wantAttempt: 3},
+		{name: "out of attempts", policy: Policy{Backoff: fast, MaxAttempts: 2}, errs: []error{errTransient, errTransient, errTransient}, wantErr: errTransient, wantAttempt: 2},
+		{
+			name:        "not retryable",
+			policy:      Policy{Backoff: fast, Retryable: func(err error) bool { return errors.Is(err, errTransient) }},
+			errs:        []error{errTransient, errPermanent},
+			wantErr:     errPermanent,
+			wantAttempt: 2,
+		},
+		{name: "out of time", policy: Policy{Backoff: Constant{Interval: time.Hour}, Timeout: 5 * time.Millisecond}, errs: []error{errTransient, errTransient}, wantErr: errTransient, wantAttempt: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var retries []RetryAttempt
+			tt.policy.OnRetry = func(r RetryAttempt) { retries = append(retries, r) }
+			attempt := 0
+			err := Do(context.Background(), tt.policy, func(ctx context.Context) error {
+				attempt++
+				if attempt <= len(tt.errs) {
+					return tt.errs[attempt-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if attempt != tt.wantAttempt {
+				t.Errorf("attempts = %d, want %d", attempt, tt.wantAttempt)
+			}
+			if len(retries) != tt.wantAttempt-1 {
+				t.Errorf("OnRetry called %d time(s), want %d", len(retries), tt.wantAttempt-1)
+			}
+		})
+	}
+}
+
+func TestDoCanceledWhileWaiting(t *testing.T) {
+	errTransient := errors.New("unavailable")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := Do(ctx, Policy{Backoff: Constant{Interval: time.Hour}, Timeout: 2 * time.Hour}, func(ctx context.Context) error {
+		return errTransient
+	})
+	if !errors.Is(err, errTransient) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do() error = %v, want %v joined with %v", err, errTransient, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Do() took %v, want it to stop waiting once ctx is done", elapsed)
+	}
+}
+
+func TestDoValue(t *testing.T) {
+	attempt := 0
+	got, err := DoValue(context.Background(), Policy{Backoff: Constant{Interval: time.Millisecond}, AttemptTimeout: time.Minute}, func(ctx context.Context) (string, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("attempt without deadline")
+		}
+		if attempt++; attempt < 2 {
+			return "", errors.New("unavailable")
+		}
+		return "listing", nil
+	})
+	if err != nil || got != "listing" {
+		t.Errorf("DoValue() = %q, %v, want %q", got, err, "listing")
+	}
+}
@@ -0,0 +1,3 @@
+module utils
+
+go 1.23.1
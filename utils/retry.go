@@ -1,12 +1,12 @@
 package utils
 
-import (
-	"math/rand"
-	"time"
-)
+import "time"
 
 const (
-	// Retry configuration
+	// Retry configuration. MaxTotalAttempts caps the retries, not the attempts:
+	// RetryState.Attempt counts the backoffs taken, so a call makes up to
+	// MaxTotalAttempts+1 attempts, as many as a Policy with MaxAttempts (which
+	// counts the first attempt too) of MaxTotalAttempts+1.
 	MaxTotalAttempts     = 3
 	InitialRetryInterval = 200 * time.Millisecond
 	MaxTotalTimeout      = 2 * time.Second
@@ -18,15 +18,42 @@ type RetryState struct {
 	Attempt     int
 	LastBackoff time.Duration
 	StartTime   time.Time
+
+	clock  Clock
+	random Rand
 }
 
 // NewRetryState creates a new retry state
 func NewRetryState() *RetryState {
+	return NewRetryStateWith(nil, nil)
+}
+
+// NewRetryStateWith creates a new retry state that reads the time from clock and
+// draws the jitter from random; nil uses the system clock and math/rand.
+func NewRetryStateWith(clock Clock, random Rand) *RetryState {
+	if clock == nil {
+		clock = systemClock{}
+	}
 	return &RetryState{
 		Attempt:     0,
 		LastBackoff: InitialRetryInterval,
-		StartTime:   time.Now(),
+		StartTime:   clock.Now(),
+		clock:       clock,
+		random:      random,
+	}
+}
+
+// Elapsed returns the time since the operation started, read from the clock of rs.
+func (rs *RetryState) Elapsed() time.Duration {
+	if rs.clock == nil {
+		return time.Since(rs.StartTime)
 	}
+	return rs.clock.Now().Sub(rs.StartTime)
+}
+
+// float64 returns a random number in [0, 1) for the jitter.
+func (rs *RetryState) float64() float64 {
+	return randFloat64(rs.random)
 }
 
 // NextBackoff calculates the next backoff duration with exponential increase and jitter
@@ -40,10 +67,10 @@ func (rs *RetryState) NextBackoff() time.Duration {
 
 	// Add jitter
 	jitter := float64(backoff) * JitterFactor
-	backoff = backoff + time.Duration(rand.Float64()*jitter)
+	backoff = backoff + time.Duration(rs.float64()*jitter)
 
 	// Check if adding this backoff would exceed the total timeout
-	elapsed := time.Since(rs.StartTime)
+	elapsed := rs.Elapsed()
 	if elapsed+backoff > MaxTotalTimeout {
 		// If we would exceed the timeout, return the remaining time
 		remaining := MaxTotalTimeout - elapsed
@@ -59,13 +86,33 @@ func (rs *RetryState) NextBackoff() time.Duration {
 	return backoff
 }
 
+// Next makes rs the Backoff of a Policy, see Policy.
+func (rs *RetryState) Next(n int, prev time.Duration) time.Duration {
+	return rs.NextBackoff()
+}
+
+// Policy returns a Policy for Do that retries the errors retryable accepts with
+// the backoff, limits and clock of rs, so GetRetryMetrics describes the call.
+// Its MaxAttempts is MaxTotalAttempts+1, the first attempt plus MaxTotalAttempts
+// retries. rs is good for one call.
+func (rs *RetryState) Policy(retryable func(error) bool) Policy {
+	return Policy{
+		Backoff:     rs,
+		MaxAttempts: MaxTotalAttempts + 1,
+		Timeout:     MaxTotalTimeout,
+		Retryable:   retryable,
+		Clock:       rs.clock,
+		Rand:        rs.random,
+	}
+}
+
 // ShouldRetry determines if another retry attempt should be made
 func (rs *RetryState) ShouldRetry() bool {
 	if rs.Attempt >= MaxTotalAttempts {
 		return false
 	}
 	// Also check if we've exceeded the total timeout
-	return time.Since(rs.StartTime) < MaxTotalTimeout
+	return rs.Elapsed() < MaxTotalTimeout
 }
 
 // GetRetryMetrics returns metrics about the retry attempts
@@ -73,7 +120,7 @@ func (rs *RetryState) GetRetryMetrics() map[string]interface{} {
 	return map[string]interface{}{
 		"attempt":      rs.Attempt,
 		"last_backoff": rs.LastBackoff,
-		"total_time":   time.Since(rs.StartTime),
+		"total_time":   rs.Elapsed(),
 		"timeout":      MaxTotalTimeout,
 	}
 }
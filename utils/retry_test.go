@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fixedRand always draws the same number.
+type fixedRand float64
+
+func (r fixedRand) Float64() float64 { return float64(r) }
+
+var epoch = time.Date(2024, 12, 1, 10, 0, 0, 0, time.UTC)
+
+func TestRetryStateNextBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		random  fixedRand
+		elapsed []time.Duration // clock advance before each call
+		want    []time.Duration
+	}{
+		{
+			name:    "exponential without jitter",
+			elapsed: []time.Duration{0, 0, 0, 0},
+			want:    []time.Duration{400 * time.Millisecond, 800 * time.Millisecond, 1600 * time.Millisecond, 0}, // capped by the attempts
+		},
+		{
+			name:    "half the jitter",
+			random:  0.5,
+			elapsed: []time.Duration{0, 0},
+			want:    []time.Duration{440 * time.Millisecond, 968 * time.Millisecond},
+		},
+		{
+			name:    "capped to the remaining time",
+			elapsed: []time.Duration{0, 1500 * time.Millisecond},
+			want:    []time.Duration{400 * time.Millisecond, 500 * time.Millisecond}, // 800ms wanted, 2s - 1.5s left
+		},
+		{
+			name:    "out of time",
+			elapsed: []time.Duration{MaxTotalTimeout},
+			want:    []time.Duration{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(epoch)
+			rs := NewRetryStateWith(clock, tt.random)
+			for i, want := range tt.want {
+				clock.Advance(tt.elapsed[i])
+				if got := rs.NextBackoff(); got != want {
+					t.Errorf("call %d: NextBackoff() = %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryStateCappedBackoffIsNotCounted(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	rs := NewRetryStateWith(clock, fixedRand(0))
+	clock.Advance(MaxTotalTimeout + time.Millisecond)
+
+	if got := rs.NextBackoff(); got != 0 {
+		t.Errorf("NextBackoff() = %v, want 0", got)
+	}
+	if rs.Attempt != 0 || rs.LastBackoff != InitialRetryInterval {
+		t.Errorf("state = attempt %d, last backoff %v, want it unchanged", rs.Attempt, rs.LastBackoff)
+	}
+}
+
+func TestRetryStateShouldRetry(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	rs := NewRetryStateWith(clock, fixedRand(0))
+	if !rs.ShouldRetry() {
+		t.Fatal("ShouldRetry() = false before any retry")
+	}
+
+	clock.Advance(MaxTotalTimeout - time.Nanosecond)
+	if !rs.ShouldRetry() {
+		t.Error("ShouldRetry() = false with 1ns left")
+	}
+	clock.Advance(time.Nanosecond)
+	if rs.ShouldRetry() {
+		t.Error("ShouldRetry() = true once MaxTotalTimeout has passed")
+	}
+	if got := rs.GetRetryMetrics()["total_time"]; got != MaxTotalTimeout {
+		t.Errorf("total_time = %v, want %v", got, MaxTotalTimeout)
+	}
+}
+
+func TestRetryStatePolicy(t *testing.T) {
+	errBoom := errors.New("boom")
+	clock := NewFakeClock(epoch)
+	rs := NewRetryStateWith(clock, fixedRand(0))
+
+	// Each attempt takes 1.9s on the fake clock: the wait is cut to the 100ms left,
+	// and no retry starts after the second attempt
+	attempts := 0
+	err := Do(context.Background(), rs.Policy(nil), func(ctx context.Context) error {
+		attempts++
+		clock.Advance(1900 * time.Millisecond)
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) || attempts != 2 {
+		t.Fatalf("Do() = %v after %d attempt(s), want %v after 2", err, attempts, errBoom)
+	}
+	metrics := rs.GetRetryMetrics()
+	if metrics["attempt"] != 1 || metrics["last_backoff"] != 100*time.Millisecond || metrics["total_time"] != 3800*time.Millisecond {
+		t.Errorf("GetRetryMetrics() = %v, want 1 attempt, 100ms last backoff, 3.8s total", metrics)
+	}
+}
+
+func TestMaxTotalAttempts(t *testing.T) {
+	// MaxTotalAttempts caps the retries: a RetryState loop, its Policy and the zero
+	// Policy all make MaxTotalAttempts+1 attempts
+	rs := NewRetryStateWith(NewFakeClock(epoch), fixedRand(0))
+	attempts := 1
+	for rs.ShouldRetry() && rs.NextBackoff() > 0 {
+		attempts++
+	}
+	if attempts != MaxTotalAttempts+1 {
+		t.Errorf("RetryState: %d attempts, want %d", attempts, MaxTotalAttempts+1)
+	}
+
+	policy := NewRetryState().Policy(nil)
+	fast := Constant{Interval: time.Millisecond}
+	policy.Backoff = fast
+	for name, p := range map[string]Policy{"RetryState.Policy": policy, "zero Policy": {Backoff: fast}} {
+		attempts := 0
+		Do(context.Background(), p, func(ctx context.Context) error {
+			attempts++
+			return errors.New("boom")
+		})
+		if attempts != MaxTotalAttempts+1 {
+			t.Errorf("%s: %d attempts, want %d", name, attempts, MaxTotalAttempts+1)
+		}
+	}
+}